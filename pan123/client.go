@@ -0,0 +1,85 @@
+package pan123
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// Client 123云盘SDK的公开接口, *Pan123 实现了该接口
+//
+// 业务代码依赖Client而非*Pan123时, 单元测试可替换为 pan123mock.Mock
+type Client interface {
+	// accessToken
+	GetAccessToken() string
+	SetAccessToken(accessToken string)
+	RequestAccessToken(clientID, clientSecret string) (string, time.Time, error)
+	RequestAccessTokenContext(ctx context.Context, clientID, clientSecret string) (string, time.Time, error)
+
+	// 文件管理
+	MkDir(name string, parentID int64) (*MkDirRespData, error)
+	MkDirContext(ctx context.Context, name string, parentID int64) (*MkDirRespData, error)
+	MoveFile(fileIDs []int64, toParentFileID int64) error
+	MoveFileContext(ctx context.Context, fileIDs []int64, toParentFileID int64) error
+	TrashFile(fileIDs []int64) error
+	TrashFileContext(ctx context.Context, fileIDs []int64) error
+	RecoverFile(fileIDs []int64) error
+	RecoverFileContext(ctx context.Context, fileIDs []int64) error
+	DeleteFile(fileIDs []int64) error
+	DeleteFileContext(ctx context.Context, fileIDs []int64) error
+	RenameFile(renameList []string) error
+	RenameFileContext(ctx context.Context, renameList []string) error
+	GetFileDetail(fileID int64) (*GetFileDetailRespData, error)
+	GetFileDetailContext(ctx context.Context, fileID int64) (*GetFileDetailRespData, error)
+
+	// 文件列表
+	GetFileList(parentFileId, page, limit int64, orderBy, orderDirection string, trashed bool, searchData string) (*GetFileListRespData, error)
+	GetFileListContext(ctx context.Context, parentFileId, page, limit int64, orderBy, orderDirection string, trashed bool, searchData string) (*GetFileListRespData, error)
+	GetFileListV2(parentFileId, limit int64, searchData string, searchMode, lastFileId int64) (*GetFileListRespDataV2, error)
+	GetFileListV2Context(ctx context.Context, parentFileId, limit int64, searchData string, searchMode, lastFileId int64) (*GetFileListRespDataV2, error)
+
+	// 上传
+	FileUpload(parentFileID int64, filename string, file *os.File, retry int) (*FileUploadRespData, error)
+	FileUploadContext(ctx context.Context, parentFileID int64, filename string, file *os.File, retry int) (*FileUploadRespData, error)
+	FileUploadWithCallback(parentFileID int64, filename string, file *os.File, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error)
+	FileUploadWithCallbackContext(ctx context.Context, parentFileID int64, filename string, file *os.File, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error)
+	FileUploadFromReaderAt(parentFileID int64, filename string, r io.ReaderAt, size int64, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error)
+	FileUploadFromReaderAtContext(ctx context.Context, parentFileID int64, filename string, r io.ReaderAt, size int64, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error)
+	FileUploadFromReadSeeker(parentFileID int64, filename string, r io.ReadSeeker, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error)
+	FileUploadFromReadSeekerContext(ctx context.Context, parentFileID int64, filename string, r io.ReadSeeker, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error)
+	UploadStream(parentFileID int64, filename string, r io.Reader, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error)
+	UploadStreamContext(ctx context.Context, parentFileID int64, filename string, r io.Reader, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error)
+	GetUploadAsyncResult(preuploadID string) (*UploadAsyncResultRespData, error)
+	GetUploadAsyncResultContext(ctx context.Context, preuploadID string) (*UploadAsyncResultRespData, error)
+
+	// 直链
+	EnableDirectLink(fileID int64) (*EnableDirectLinkRespData, error)
+	EnableDirectLinkContext(ctx context.Context, fileID int64) (*EnableDirectLinkRespData, error)
+	DisableDirectLink(fileID int64) (*DisableDirectLinkRespData, error)
+	DisableDirectLinkContext(ctx context.Context, fileID int64) (*DisableDirectLinkRespData, error)
+	GetDirectLinkUrl(fileID int64) (*GetDirectLinkUrlRespData, error)
+	GetDirectLinkUrlContext(ctx context.Context, fileID int64) (*GetDirectLinkUrlRespData, error)
+	QueryDirectLinkTranscode(ids []int64) (*QueryDirectLinkTranscodeRespData, error)
+	QueryDirectLinkTranscodeContext(ctx context.Context, ids []int64) (*QueryDirectLinkTranscodeRespData, error)
+	DoDirectLinkTranscode(ids []int64) error
+	DoDirectLinkTranscodeContext(ctx context.Context, ids []int64) error
+	GetDirectLinkM3u8(fileID int64) (*GetDirectLinkM3u8RespData, error)
+	GetDirectLinkM3u8Context(ctx context.Context, fileID int64) (*GetDirectLinkM3u8RespData, error)
+
+	// 分享
+	CreateShare(shareName, fileIDList, sharePwd string, shareExpire int) (*CreateShareRespData, error)
+	CreateShareContext(ctx context.Context, shareName, fileIDList, sharePwd string, shareExpire int) (*CreateShareRespData, error)
+
+	// 离线下载
+	OfflineDownload(url, fileName, callBackUrl string, dirID int64) (*OfflineDownloadRespData, error)
+	OfflineDownloadContext(ctx context.Context, url, fileName, callBackUrl string, dirID int64) (*OfflineDownloadRespData, error)
+	GetOfflineDownloadProcess(taskID int64) (*GetOfflineDownloadProcessRespData, error)
+	GetOfflineDownloadProcessContext(ctx context.Context, taskID int64) (*GetOfflineDownloadProcessRespData, error)
+
+	// 用户
+	GetUserInfo() (*GetUserInfoRespData, error)
+	GetUserInfoContext(ctx context.Context) (*GetUserInfoRespData, error)
+}
+
+var _ Client = (*Pan123)(nil)
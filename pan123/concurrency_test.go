@@ -0,0 +1,107 @@
+// go test -race -run Concurrent ./pan123
+package pan123
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123test"
+)
+
+// newTestServer 接受任意accessToken, 分块大小为4字节的模拟服务
+func newTestServer() *pan123test.Server {
+	return pan123test.NewServer(pan123test.WithSliceSize(4), pan123test.WithoutTokenCheck())
+}
+
+func TestConcurrentClientUse(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "upload.txt")
+	if err := os.WriteFile(path, []byte("hello concurrent world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p123 := NewPan123WithOptions(
+		WithBaseURL(srv.URL),
+		// 保留限流器, 但放宽额度以缩短测试时间
+		WithRateLimit("/upload/v1/file/mkdir", RateLimit{QPS: 1000, Burst: 4}),
+		WithRateLimit("/upload/v1/file/create", RateLimit{QPS: 1000, Burst: 4}),
+		WithRateLimit("/api/v2/file/list", RateLimit{QPS: 1000, Burst: 4}),
+	)
+	p123.SetAccessToken("token")
+
+	const workers = 16
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			// 并发读写accessToken
+			p123.SetAccessToken("token")
+			if p123.GetAccessToken() == "" {
+				t.Error("GetAccessToken() empty")
+			}
+			if _, err := p123.MkDirContext(ctx, fmt.Sprintf("dir-%d", i), 0); err != nil {
+				t.Error(err)
+			}
+			if _, err := p123.GetFileListV2Context(ctx, 0, 100, "", -1, -1); err != nil {
+				t.Error(err)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer file.Close()
+			resp, err := p123.FileUploadContext(ctx, 0, fmt.Sprintf("upload-%d.txt", i), file, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if resp.FileID == 0 {
+				t.Error("FileID = 0")
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestConcurrentTokenSource(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	var mu sync.Mutex
+	fetches := 0
+	ts := NewRefreshTokenSource(func(ctx context.Context) (*Token, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		return &Token{AccessToken: fmt.Sprintf("token-%d", fetches), ExpiredAt: time.Now().Add(time.Hour)}, nil
+	}, time.Minute)
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithTokenSource(ts), WithoutRateLimit())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%8 == 0 {
+				ts.Invalidate("token-1")
+			}
+			if _, err := p123.GetUserInfo(); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,63 @@
+package pan123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := p123.MkDirContext(ctx, "dir", 0)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("request was not cancelled in time: %s", time.Since(start))
+	}
+}
+
+func TestFileUploadContextCancelled(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "upload.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := p123.FileUploadContext(ctx, 0, "upload.txt", file, 0); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := atomic.LoadInt32(&hits); n != 0 {
+		t.Fatalf("server hit %d times after cancellation", n)
+	}
+}
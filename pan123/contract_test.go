@@ -0,0 +1,241 @@
+package pan123
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	netUrl "net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123test"
+)
+
+// capturedRequest 发往OpenAPI的请求及响应data
+type capturedRequest struct {
+	method string
+	url    *netUrl.URL
+	header http.Header
+	body   []byte
+	data   json.RawMessage
+}
+
+// captureTransport 记录所有请求, 分块上传的PUT请求除外
+type captureTransport struct {
+	mu       sync.Mutex
+	requests []capturedRequest
+}
+
+func (ct *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil || req.Method == "PUT" {
+		return resp, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	var apiResp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	_ = json.Unmarshal(respBody, &apiResp)
+	ct.mu.Lock()
+	ct.requests = append(ct.requests, capturedRequest{method: req.Method, url: req.URL, header: req.Header.Clone(), body: body, data: apiResp.Data})
+	ct.mu.Unlock()
+	return resp, nil
+}
+
+// TestEndpointContract 通过模拟服务调用所有公开方法, 校验请求与接口目录一致
+func TestEndpointContract(t *testing.T) {
+	srv := pan123test.NewServer(pan123test.WithSliceSize(4), pan123test.WithAsyncUpload(1))
+	defer srv.Close()
+	ct := &captureTransport{}
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithTransport(ct), WithoutRateLimit())
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	accessToken, _, err := p123.RequestAccessToken(pan123test.DefaultClientID, pan123test.DefaultClientSecret)
+	must(err)
+	p123.SetAccessToken(accessToken)
+	_, err = p123.GetUserInfo()
+	must(err)
+
+	dir, err := p123.MkDir("contract", 0)
+	must(err)
+	path := filepath.Join(t.TempDir(), "video.mp4")
+	must(os.WriteFile(path, []byte("contract test video content"), 0600))
+	file, err := os.Open(path)
+	must(err)
+	defer file.Close()
+	// 分块大小为4字节, 上传时会校验分块(list_upload_parts)并异步完成
+	upload, err := p123.FileUpload(dir.DirID, "video.mp4", file, 0)
+	must(err)
+	asyncResult, err := p123.GetUploadAsyncResult(upload.PreuploadID)
+	must(err)
+	fileID := asyncResult.FileID
+
+	_, err = p123.GetFileList(dir.DirID, 1, 100, "file_id", "asc", false, "video")
+	must(err)
+	_, err = p123.GetFileListV2(dir.DirID, 100, "video", 1, -1)
+	must(err)
+	_, err = p123.GetFileDetail(fileID)
+	must(err)
+	must(p123.RenameFile([]string{strconv.FormatInt(fileID, 10) + "|movie.mp4"}))
+
+	_, err = p123.CreateShare("share", strconv.FormatInt(fileID, 10), "pwd", 7)
+	must(err)
+
+	_, err = p123.EnableDirectLink(dir.DirID)
+	must(err)
+	_, err = p123.GetDirectLinkUrl(fileID)
+	must(err)
+	must(p123.DoDirectLinkTranscode([]int64{fileID}))
+	_, err = p123.QueryDirectLinkTranscode([]int64{fileID})
+	must(err)
+	_, err = p123.GetDirectLinkM3u8(fileID)
+	must(err)
+	_, err = p123.DisableDirectLink(dir.DirID)
+	must(err)
+
+	task, err := p123.OfflineDownload("https://example.com/a.zip", "a.zip", "https://example.com/callback", dir.DirID)
+	must(err)
+	_, err = p123.GetOfflineDownloadProcess(task.TaskID)
+	must(err)
+
+	sub, err := p123.MkDir("sub", dir.DirID)
+	must(err)
+	must(p123.MoveFile([]int64{fileID}, sub.DirID))
+	must(p123.TrashFile([]int64{fileID}))
+	must(p123.RecoverFile([]int64{fileID}))
+	must(p123.TrashFile([]int64{fileID}))
+	must(p123.DeleteFile([]int64{fileID}))
+
+	srvURL, _ := netUrl.Parse(srv.URL)
+	seen := map[string]bool{}
+	for _, req := range ct.requests {
+		ep, ok := LookupEndpoint(req.url.Path)
+		if !ok {
+			t.Errorf("request to unknown endpoint %s %s", req.method, req.url)
+			continue
+		}
+		seen[ep.Path] = true
+		if req.url.Scheme != srvURL.Scheme || req.url.Host != srvURL.Host || strings.Contains(req.url.Path, "//") {
+			t.Errorf("%s: malformed url %s", ep.Name, req.url)
+		}
+		if req.method != ep.Method {
+			t.Errorf("%s: method %s, want %s", ep.Name, req.method, ep.Method)
+		}
+		if got := req.header.Get("Authorization") != ""; got != ep.Auth {
+			t.Errorf("%s: Authorization header present = %v, want %v", ep.Name, got, ep.Auth)
+		}
+		if req.header.Get("Platform") == "" {
+			t.Errorf("%s: missing Platform header", ep.Name)
+		}
+
+		var params []string
+		if ep.Method == "GET" {
+			if len(req.body) != 0 {
+				t.Errorf("%s: GET request with body %s", ep.Name, req.body)
+			}
+			for k := range req.url.Query() {
+				params = append(params, k)
+			}
+		} else {
+			if req.url.RawQuery != "" {
+				t.Errorf("%s: POST request with query %s", ep.Name, req.url.RawQuery)
+			}
+			if ct := req.header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("%s: Content-Type %q", ep.Name, ct)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(req.body, &body); err != nil {
+				t.Errorf("%s: body is not a JSON object: %s", ep.Name, req.body)
+			}
+			for k := range body {
+				params = append(params, k)
+			}
+		}
+		for _, param := range params {
+			if !containsString(ep.Params, param) {
+				t.Errorf("%s: unexpected param %q", ep.Name, param)
+			}
+		}
+
+		if ep.Response != nil {
+			v := reflect.New(ep.Response).Interface()
+			if err := json.Unmarshal(req.data, v); err != nil {
+				t.Errorf("%s: decode data into %s: %s", ep.Name, ep.Response, err)
+			}
+		}
+	}
+	for _, ep := range Endpoints() {
+		if !seen[ep.Path] {
+			t.Errorf("endpoint %s (%s) not exercised", ep.Name, ep.Path)
+		}
+	}
+}
+
+func TestEndpointCatalog(t *testing.T) {
+	names := map[string]bool{}
+	for _, ep := range Endpoints() {
+		if !strings.HasPrefix(ep.Path, "/") || strings.HasSuffix(ep.Path, "/") {
+			t.Errorf("%s: path %q must start and not end with /", ep.Name, ep.Path)
+		}
+		if ep.Method != "GET" && ep.Method != "POST" {
+			t.Errorf("%s: method %q", ep.Name, ep.Method)
+		}
+		if ep.Method == "GET" && !ep.Idempotent {
+			t.Errorf("%s: GET endpoint must be idempotent", ep.Name)
+		}
+		if names[ep.Name] {
+			t.Errorf("duplicate endpoint name %s", ep.Name)
+		}
+		names[ep.Name] = true
+	}
+
+	// 修复前OfflineDownload的路径缺少开头的/
+	p123 := NewPan123WithOptions(WithBaseURL("https://open-api.123pan.com"))
+	if got := p123.baseURL + epOfflineDownload.Path; got != "https://open-api.123pan.com/api/v1/offline/download" {
+		t.Errorf("offline download url = %s", got)
+	}
+
+	limits := DefaultRateLimits()
+	if limits[epFileListV2.Path].QPS != 3 || !isIdempotent("POST", epUploadComplete.Path) || isIdempotent("POST", epFileMove.Path) {
+		t.Errorf("rate limits or idempotency not derived from catalog: %v", limits)
+	}
+	if _, ok := LookupEndpoint("/api/v1/unknown"); ok {
+		t.Error("LookupEndpoint found unknown path")
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package pan123
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// staticTransport 对所有请求返回相同的响应
+type staticTransport struct {
+	body []byte
+}
+
+func (t staticTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(t.body)),
+		Request:    req,
+	}, nil
+}
+
+func fileListV2Body(n int) []byte {
+	files := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		files = append(files, fmt.Sprintf(`{"fileID":%d,"filename":"file-%d.mp4","type":0,"size":%d,"etag":"d41d8cd98f00b204e9800998ecf8427e","status":0,"parentFileID":9007199254740993,"category":2}`,
+			9007199254740993+int64(i), i, 1<<40+i))
+	}
+	return []byte(`{"code":0,"message":"ok","x-traceID":"trace","data":{"lastFileId":-1,"fileList":[` + strings.Join(files, ",") + `]}}`)
+}
+
+func TestDecodeRespDataPreservesInt64(t *testing.T) {
+	p123 := NewPan123WithOptions(WithTransport(staticTransport{body: fileListV2Body(2)}), WithoutRateLimit())
+	p123.SetAccessToken("token")
+	resp, err := p123.GetFileListV2(0, 100, "", -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2^53+1 无法用float64精确表示
+	if resp.FileList[0].FileID != 9007199254740993 || resp.FileList[1].FileID != 9007199254740994 {
+		t.Fatalf("FileID = %d, %d", resp.FileList[0].FileID, resp.FileList[1].FileID)
+	}
+	if resp.FileList[0].ParentFileID != 9007199254740993 {
+		t.Fatalf("ParentFileID = %d", resp.FileList[0].ParentFileID)
+	}
+}
+
+func TestDecodeRespDataEmpty(t *testing.T) {
+	for _, data := range []json.RawMessage{nil, json.RawMessage("null")} {
+		respData, err := decodeRespData[MkDirRespData](data)
+		if err != nil || respData == nil || respData.DirID != 0 {
+			t.Fatalf("decodeRespData(%q) = %+v, %v", data, respData, err)
+		}
+	}
+	if _, err := decodeRespData[MkDirRespData](json.RawMessage(`{"dirID":"x"}`)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// decodeViaMap 原实现: 先解析为map[string]interface{}, 再重新编码后解析为目标结构体
+func decodeViaMap(body []byte, o interface{}) error {
+	var r struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return err
+	}
+	b, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, o)
+}
+
+func BenchmarkFileListV2Decode(b *testing.B) {
+	body := fileListV2Body(100)
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var respData GetFileListRespDataV2
+			if err := decodeViaMap(body, &respData); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("raw", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var r apiHttpResp
+			if err := json.Unmarshal(body, &r); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := decodeRespData[GetFileListRespDataV2](r.Data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetFileListV2(b *testing.B) {
+	p123 := NewPan123WithOptions(WithTransport(staticTransport{body: fileListV2Body(100)}), WithoutRateLimit())
+	p123.SetAccessToken("token")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p123.GetFileListV2(0, 100, "", -1, -1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
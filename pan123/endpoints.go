@@ -0,0 +1,229 @@
+package pan123
+
+import "reflect"
+
+// Endpoint OpenAPI接口描述
+type Endpoint struct {
+	// 调用该接口的SDK方法名, 例如: MoveFile, 内部接口以小写字母开头
+	Name string
+	// 接口路径, 例如: /api/v1/file/move
+	Path string
+	// HTTP方法
+	Method string
+	// 是否需要accessToken
+	Auth bool
+	// 重复请求是否安全, 幂等的接口在出错时会自动重试
+	Idempotent bool
+	// 推荐的限流配置, 通过WithDefaultRateLimits启用, QPS为0时不限流
+	RateLimit RateLimit
+	// 请求参数名, GET接口为query参数, POST接口为JSON body的字段
+	Params []string
+	// 响应data的类型, 接口不返回data时为nil
+	Response reflect.Type
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+var (
+	epAccessToken = &Endpoint{
+		Name: "RequestAccessToken", Path: "/api/v1/access_token", Method: "POST", Auth: false, Idempotent: true,
+		RateLimit: RateLimit{QPS: 1, Burst: 1},
+		Params:    []string{"clientID", "clientSecret"},
+		Response:  typeOf[loginRespData](),
+	}
+	epUserInfo = &Endpoint{
+		Name: "GetUserInfo", Path: "/api/v1/user/info", Method: "GET", Auth: true, Idempotent: true,
+		RateLimit: RateLimit{QPS: 1, Burst: 1},
+		Response:  typeOf[GetUserInfoRespData](),
+	}
+
+	epMkDir = &Endpoint{
+		Name: "MkDir", Path: "/upload/v1/file/mkdir", Method: "POST", Auth: true,
+		RateLimit: RateLimit{QPS: 2, Burst: 1},
+		Params:    []string{"name", "parentID"},
+		Response:  typeOf[MkDirRespData](),
+	}
+	epFileList = &Endpoint{
+		Name: "GetFileList", Path: "/api/v1/file/list", Method: "GET", Auth: true, Idempotent: true,
+		RateLimit: RateLimit{QPS: 4, Burst: 1},
+		Params:    []string{"parentFileId", "page", "limit", "orderBy", "orderDirection", "trashed", "searchData"},
+		Response:  typeOf[GetFileListRespData](),
+	}
+	epFileListV2 = &Endpoint{
+		Name: "GetFileListV2", Path: "/api/v2/file/list", Method: "GET", Auth: true, Idempotent: true,
+		RateLimit: RateLimit{QPS: 3, Burst: 1},
+		Params:    []string{"parentFileId", "limit", "searchData", "searchMode", "lastFileId"},
+		Response:  typeOf[GetFileListRespDataV2](),
+	}
+	epFileMove = &Endpoint{
+		Name: "MoveFile", Path: "/api/v1/file/move", Method: "POST", Auth: true,
+		RateLimit: RateLimit{QPS: 1, Burst: 1},
+		Params:    []string{"fileIDs", "toParentFileID"},
+	}
+	epFileTrash = &Endpoint{
+		Name: "TrashFile", Path: "/api/v1/file/trash", Method: "POST", Auth: true,
+		Params: []string{"fileIDs"},
+	}
+	epFileRecover = &Endpoint{
+		Name: "RecoverFile", Path: "/api/v1/file/recover", Method: "POST", Auth: true,
+		Params: []string{"fileIDs"},
+	}
+	epFileDelete = &Endpoint{
+		Name: "DeleteFile", Path: "/api/v1/file/delete", Method: "POST", Auth: true,
+		RateLimit: RateLimit{QPS: 1, Burst: 1},
+		Params:    []string{"fileIDs"},
+	}
+	epFileRename = &Endpoint{
+		Name: "RenameFile", Path: "/api/v1/file/rename", Method: "POST", Auth: true,
+		Params: []string{"renameList"},
+	}
+	epFileDetail = &Endpoint{
+		Name: "GetFileDetail", Path: "/api/v1/file/detail", Method: "GET", Auth: true, Idempotent: true,
+		Params:   []string{"fileID"},
+		Response: typeOf[GetFileDetailRespData](),
+	}
+
+	epUploadCreate = &Endpoint{
+		Name: "fileUploadCreateFile", Path: "/upload/v1/file/create", Method: "POST", Auth: true,
+		RateLimit: RateLimit{QPS: 2, Burst: 1},
+		Params:    []string{"parentFileID", "filename", "etag", "size"},
+		Response:  typeOf[fileUploadCreateFileRespData](),
+	}
+	epUploadGetURL = &Endpoint{
+		Name: "fileUploadGetChunkUploadUrl", Path: "/upload/v1/file/get_upload_url", Method: "POST", Auth: true, Idempotent: true,
+		Params:   []string{"preuploadID", "sliceNo"},
+		Response: typeOf[fileUploadGetChunkUploadUrlRespData](),
+	}
+	epUploadListParts = &Endpoint{
+		Name: "fileUploadListUploadParts", Path: "/upload/v1/file/list_upload_parts", Method: "POST", Auth: true, Idempotent: true,
+		Params:   []string{"preuploadID"},
+		Response: typeOf[fileUploadListUploadPartsRespData](),
+	}
+	epUploadComplete = &Endpoint{
+		Name: "fileUploadUploadComplete", Path: "/upload/v1/file/upload_complete", Method: "POST", Auth: true, Idempotent: true,
+		Params:   []string{"preuploadID"},
+		Response: typeOf[fileUploadUploadCompleteRespData](),
+	}
+	epUploadAsyncResult = &Endpoint{
+		Name: "GetUploadAsyncResult", Path: "/upload/v1/file/upload_async_result", Method: "POST", Auth: true, Idempotent: true,
+		Params:   []string{"preuploadID"},
+		Response: typeOf[UploadAsyncResultRespData](),
+	}
+
+	epShareCreate = &Endpoint{
+		Name: "CreateShare", Path: "/api/v1/share/create", Method: "POST", Auth: true,
+		Params:   []string{"shareName", "fileIDList", "sharePwd", "shareExpire"},
+		Response: typeOf[CreateShareRespData](),
+	}
+
+	epDirectLinkEnable = &Endpoint{
+		Name: "EnableDirectLink", Path: "/api/v1/direct-link/enable", Method: "POST", Auth: true, Idempotent: true,
+		Params:   []string{"fileID"},
+		Response: typeOf[EnableDirectLinkRespData](),
+	}
+	epDirectLinkDisable = &Endpoint{
+		Name: "DisableDirectLink", Path: "/api/v1/direct-link/disable", Method: "POST", Auth: true, Idempotent: true,
+		Params:   []string{"fileID"},
+		Response: typeOf[DisableDirectLinkRespData](),
+	}
+	epDirectLinkURL = &Endpoint{
+		Name: "GetDirectLinkUrl", Path: "/api/v1/direct-link/url", Method: "GET", Auth: true, Idempotent: true,
+		Params:   []string{"fileID"},
+		Response: typeOf[GetDirectLinkUrlRespData](),
+	}
+	epDirectLinkQueryTranscode = &Endpoint{
+		Name: "QueryDirectLinkTranscode", Path: "/api/v1/direct-link/queryTranscode", Method: "POST", Auth: true, Idempotent: true,
+		Params:   []string{"ids"},
+		Response: typeOf[QueryDirectLinkTranscodeRespData](),
+	}
+	epDirectLinkDoTranscode = &Endpoint{
+		Name: "DoDirectLinkTranscode", Path: "/api/v1/direct-link/doTranscode", Method: "POST", Auth: true,
+		Params: []string{"ids"},
+	}
+	epDirectLinkM3u8 = &Endpoint{
+		Name: "GetDirectLinkM3u8", Path: "/api/v1/direct-link/get/m3u8", Method: "GET", Auth: true, Idempotent: true,
+		Params:   []string{"fileID"},
+		Response: typeOf[GetDirectLinkM3u8RespData](),
+	}
+
+	epOfflineDownload = &Endpoint{
+		Name: "OfflineDownload", Path: "/api/v1/offline/download", Method: "POST", Auth: true,
+		Params:   []string{"url", "fileName", "callBackUrl", "dirID"},
+		Response: typeOf[OfflineDownloadRespData](),
+	}
+	epOfflineDownloadProcess = &Endpoint{
+		Name: "GetOfflineDownloadProcess", Path: "/api/v1/offline/download/process", Method: "GET", Auth: true, Idempotent: true,
+		Params:   []string{"taskID"},
+		Response: typeOf[GetOfflineDownloadProcessRespData](),
+	}
+)
+
+// endpoints SDK使用的所有接口
+var endpoints = []*Endpoint{
+	epAccessToken,
+	epUserInfo,
+	epMkDir,
+	epFileList,
+	epFileListV2,
+	epFileMove,
+	epFileTrash,
+	epFileRecover,
+	epFileDelete,
+	epFileRename,
+	epFileDetail,
+	epUploadCreate,
+	epUploadGetURL,
+	epUploadListParts,
+	epUploadComplete,
+	epUploadAsyncResult,
+	epShareCreate,
+	epDirectLinkEnable,
+	epDirectLinkDisable,
+	epDirectLinkURL,
+	epDirectLinkQueryTranscode,
+	epDirectLinkDoTranscode,
+	epDirectLinkM3u8,
+	epOfflineDownload,
+	epOfflineDownloadProcess,
+}
+
+// endpointsByPath 按接口路径索引
+var endpointsByPath = func() map[string]*Endpoint {
+	m := make(map[string]*Endpoint, len(endpoints))
+	for _, ep := range endpoints {
+		m[ep.Path] = ep
+	}
+	return m
+}()
+
+// Endpoints SDK使用的所有接口, 返回副本
+//
+// @return []Endpoint
+func Endpoints() []Endpoint {
+	out := make([]Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		_ep := *ep
+		_ep.Params = append([]string(nil), ep.Params...)
+		out = append(out, _ep)
+	}
+	return out
+}
+
+// LookupEndpoint 根据接口路径查找接口描述
+//
+// @param path string 接口路径, 例如: /api/v1/file/move
+//
+// @return Endpoint
+//
+// @return bool
+func LookupEndpoint(path string) (Endpoint, bool) {
+	ep, ok := endpointsByPath[path]
+	if !ok {
+		return Endpoint{}, false
+	}
+	_ep := *ep
+	_ep.Params = append([]string(nil), ep.Params...)
+	return _ep, true
+}
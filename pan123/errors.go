@@ -1,27 +1,208 @@
 package pan123
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"time"
 )
 
 const (
 	defaultTraceID = "no_trace_id"
 )
 
+const (
+	// SDKErrorCodeInternal 非接口返回的错误(网络、解析、参数校验等)使用的错误码
+	SDKErrorCodeInternal = 999
+
+	// 接口返回的accessToken无效错误码
+	apiCodeUnauthorized = 401
+	// 接口返回的请求过于频繁错误码
+	apiCodeRateLimited = 429
+	// 接口返回的文件不存在错误码
+	apiCodeNotFound = 5066
+)
+
+// SDKError的错误分类, 可通过 errors.Is(err, pan123.ErrXXX) 判断
+var (
+	// ErrUnauthorized accessToken无效或已过期
+	ErrUnauthorized = errors.New("pan123: unauthorized")
+	// ErrRateLimited 请求过于频繁
+	ErrRateLimited = errors.New("pan123: rate limited")
+	// ErrNotFound 文件或资源不存在
+	ErrNotFound = errors.New("pan123: not found")
+	// ErrValidation 请求参数校验失败, 未发起请求
+	ErrValidation = errors.New("pan123: validation failed")
+	// ErrTransport 网络错误或读取响应失败
+	ErrTransport = errors.New("pan123: transport error")
+	// ErrHTTPStatus HTTP状态码非200
+	ErrHTTPStatus = errors.New("pan123: unexpected http status")
+	// ErrDecode 响应解析失败
+	ErrDecode = errors.New("pan123: decode error")
+	// ErrAPI 接口返回的其他错误码
+	ErrAPI = errors.New("pan123: api error")
+	// ErrCanceled ctx已取消或超时
+	ErrCanceled = errors.New("pan123: canceled")
+	// ErrFileIO 读取本地文件失败
+	ErrFileIO = errors.New("pan123: file io error")
+	// ErrChunkMismatch 上传分块校验失败
+	ErrChunkMismatch = errors.New("pan123: chunk mismatch")
+	// ErrUploadFailed 上传未完成
+	ErrUploadFailed = errors.New("pan123: upload failed")
+)
+
+// SDKError SDK返回的错误, 可通过 errors.Is 判断错误分类, errors.As 获取详细信息
 type SDKError struct {
+	// 接口返回的错误码, 非接口返回的错误为SDKErrorCodeInternal
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	TraceID string `json:"trace_id"`
+
+	// 错误分类, 例如: ErrUnauthorized、ErrTransport
+	Kind error `json:"-"`
+	// 请求的接口路径
+	Endpoint string `json:"endpoint,omitempty"`
+	// HTTP状态码, 仅在收到HTTP响应时存在
+	HTTPStatus int `json:"http_status,omitempty"`
+	// 原始错误
+	Cause error `json:"-"`
+	// 校验失败的参数名, 仅ErrValidation存在, 例如: fileIDs、renameList[0]
+	Field string `json:"field,omitempty"`
+
+	// 响应头Retry-After
+	retryAfter time.Duration
 }
 
 func (e *SDKError) Error() string {
 	return fmt.Sprintf("SDKError: [%d](%s): %s", e.Code, e.TraceID, e.Message)
 }
 
-func newSDKError(code int, message, traceID string) error {
+// Unwrap 返回原始错误
+func (e *SDKError) Unwrap() error {
+	return e.Cause
+}
+
+// Is 判断错误分类
+func (e *SDKError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// Retryable 错误是否为临时性错误, 重试可能成功(网络错误、限流、HTTP 5xx)
+//
+// @return bool
+func (e *SDKError) Retryable() bool {
+	switch e.Kind {
+	case ErrTransport, ErrRateLimited:
+		return true
+	case ErrHTTPStatus:
+		return e.HTTPStatus >= 500
+	}
+	return false
+}
+
+func newKindError(kind error, message string, cause error) error {
 	sdkErr := new(SDKError)
-	sdkErr.Code = code
+	sdkErr.Code = SDKErrorCodeInternal
 	sdkErr.Message = message
-	sdkErr.TraceID = traceID
+	sdkErr.TraceID = defaultTraceID
+	sdkErr.Kind = kind
+	sdkErr.Cause = cause
+	return sdkErr
+}
+
+func newCtxError(err error) error {
+	return newKindError(ErrCanceled, fmt.Sprintf("ctx error: %s", err), err)
+}
+
+func newTransportError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return newKindError(ErrCanceled, fmt.Sprintf("http error: %s", err), err)
+	}
+	return newKindError(ErrTransport, fmt.Sprintf("http error: %s", err), err)
+}
+
+func newHTTPStatusError(statusCode int, header http.Header) error {
+	sdkErr := new(SDKError)
+	sdkErr.Code = SDKErrorCodeInternal
+	sdkErr.Message = fmt.Sprintf("http_code error: %d", statusCode)
+	sdkErr.TraceID = defaultTraceID
+	sdkErr.HTTPStatus = statusCode
+	sdkErr.retryAfter = parseRetryAfter(header.Get("Retry-After"))
+	switch statusCode {
+	case http.StatusUnauthorized:
+		sdkErr.Kind = ErrUnauthorized
+	case http.StatusTooManyRequests:
+		sdkErr.Kind = ErrRateLimited
+	case http.StatusNotFound:
+		sdkErr.Kind = ErrNotFound
+	default:
+		sdkErr.Kind = ErrHTTPStatus
+	}
+	return sdkErr
+}
+
+func newApiError(r *apiHttpResp, resp *http.Response) error {
+	sdkErr := new(SDKError)
+	sdkErr.Code = r.Code
+	sdkErr.Message = r.Message
+	sdkErr.TraceID = r.TraceID
+	sdkErr.Kind = apiCodeKind(r.Code)
+	sdkErr.HTTPStatus = resp.StatusCode
+	sdkErr.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
 	return sdkErr
 }
+
+// apiCodeKind 接口错误码对应的错误分类
+func apiCodeKind(code int) error {
+	switch code {
+	case apiCodeUnauthorized:
+		return ErrUnauthorized
+	case apiCodeRateLimited:
+		return ErrRateLimited
+	case apiCodeNotFound:
+		return ErrNotFound
+	}
+	return ErrAPI
+}
+
+// withEndpoint 为SDKError补充接口路径, 返回副本, 不修改err
+func withEndpoint(err error, endpoint string) error {
+	sdkError, ok := err.(*SDKError)
+	if !ok || sdkError.Endpoint != "" {
+		return err
+	}
+	_sdkError := *sdkError
+	_sdkError.Endpoint = endpoint
+	return &_sdkError
+}
+
+// cloneError 返回SDKError的副本, 其他错误原样返回
+func cloneError(err error) error {
+	sdkError, ok := err.(*SDKError)
+	if !ok {
+		return err
+	}
+	_sdkError := *sdkError
+	return &_sdkError
+}
+
+// parseRetryAfter 解析Retry-After, 支持秒数及HTTP日期两种格式
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
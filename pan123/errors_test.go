@@ -0,0 +1,94 @@
+package pan123
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSDKErrorKinds(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		kind      error
+		code      int
+		status    int
+		retryable bool
+	}{
+		{"api unauthorized", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"code":401,"message":"token is expired","x-traceID":"trace"}`))
+		}, ErrUnauthorized, 401, 200, false},
+		{"api rate limited", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"code":429,"message":"too many requests","x-traceID":"trace"}`))
+		}, ErrRateLimited, 429, 200, true},
+		{"api other", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"code":1,"message":"failed","x-traceID":"trace"}`))
+		}, ErrAPI, 1, 200, false},
+		{"http 401", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}, ErrUnauthorized, SDKErrorCodeInternal, 401, false},
+		{"http 502", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, ErrHTTPStatus, SDKErrorCodeInternal, 502, true},
+		{"http 404", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}, ErrNotFound, SDKErrorCodeInternal, 404, false},
+		{"not json", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`<html>`))
+		}, ErrDecode, SDKErrorCodeInternal, 0, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(tc.handler)
+			defer srv.Close()
+
+			p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit())
+			_, err := p123.GetFileDetail(1)
+			if !errors.Is(err, tc.kind) {
+				t.Fatalf("errors.Is(%v, %v) = false", err, tc.kind)
+			}
+			var sdkError *SDKError
+			if !errors.As(err, &sdkError) {
+				t.Fatalf("errors.As(%v) = false", err)
+			}
+			if sdkError.Code != tc.code || sdkError.HTTPStatus != tc.status || sdkError.Retryable() != tc.retryable {
+				t.Fatalf("got code=%d status=%d retryable=%v", sdkError.Code, sdkError.HTTPStatus, sdkError.Retryable())
+			}
+			if sdkError.Endpoint != "/api/v1/file/detail" {
+				t.Fatalf("Endpoint = %q", sdkError.Endpoint)
+			}
+		})
+	}
+}
+
+func TestSDKErrorTransportUnwrap(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit())
+	_, err := p123.GetFileDetail(1)
+	if !errors.Is(err, ErrTransport) {
+		t.Fatalf("errors.Is(%v, ErrTransport) = false", err)
+	}
+	var sdkError *SDKError
+	if !errors.As(err, &sdkError) || sdkError.Cause == nil || errors.Unwrap(err) != sdkError.Cause {
+		t.Fatalf("cause not wrapped: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p123.GetFileDetailContext(ctx, 1)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled call returned %v", err)
+	}
+}
+
+func TestSDKErrorValidation(t *testing.T) {
+	p123 := NewPan123WithOptions(WithBaseURL("http://127.0.0.1:0"))
+	_, err := p123.CreateShare("share", "1", "", 3)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("errors.Is(%v, ErrValidation) = false", err)
+	}
+}
@@ -0,0 +1,211 @@
+package pan123
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	netUrl "net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LogLevel 日志级别
+type LogLevel int
+
+const (
+	// LogLevelDebug 每次请求的详细信息(已脱敏的请求参数、耗时、状态码等)
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo 重试等值得关注的事件
+	LogLevelInfo
+	// LogLevelWarn 请求失败
+	LogLevelWarn
+	// LogLevelError 保留
+	LogLevelError
+	// LogLevelOff 关闭日志
+	LogLevelOff
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	case LogLevelOff:
+		return "OFF"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// Logger 结构化日志输出
+//
+// keyvals为交替出现的键值对, 例如: "method", "POST", "endpoint", "/upload/v1/file/mkdir";
+// SDK传入的值均已脱敏, 不包含accessToken、clientSecret、提取码及预签名地址的签名
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+}
+
+// Printfer Printf风格的日志输出, *log.Logger 满足该接口
+type Printfer interface {
+	Printf(format string, v ...interface{})
+}
+
+// NewPrintfLogger 将Printf风格的日志输出适配为Logger, 输出格式为: [LEVEL] msg key=value ...
+//
+// @param printfer Printfer 例如: log.Default()
+//
+// @return Logger
+func NewPrintfLogger(printfer Printfer) Logger {
+	return printfLogger{printfer: printfer}
+}
+
+type printfLogger struct {
+	printfer Printfer
+}
+
+func (l printfLogger) Log(_ context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	sb.WriteString(level.String())
+	sb.WriteString("] ")
+	sb.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		sb.WriteString(" ")
+		sb.WriteString(fmt.Sprint(keyvals[i]))
+		sb.WriteString("=")
+		if i+1 < len(keyvals) {
+			sb.WriteString(fmt.Sprintf("%v", keyvals[i+1]))
+		}
+	}
+	l.printfer.Printf("%s\n", sb.String())
+}
+
+// WithLogger 设置日志输出, 未设置时仅在开启debug后输出至标准输出
+//
+// @param logger Logger 例如: NewPrintfLogger(log.Default())、NewSlogLogger(slog.Default())
+func WithLogger(logger Logger) Option {
+	return func(p123 *Pan123) {
+		p123.logger = logger
+	}
+}
+
+// WithLogLevel 设置日志级别, 默认开启debug时为LogLevelDebug, 否则为LogLevelInfo
+//
+// @param level LogLevel
+func WithLogLevel(level LogLevel) Option {
+	return func(p123 *Pan123) {
+		p123.logLevel = level
+		p123.logLevelSet = true
+	}
+}
+
+func newStdoutLogger() Logger {
+	return NewPrintfLogger(log.New(os.Stdout, "", log.LstdFlags))
+}
+
+// log 输出日志, keyvals需已脱敏
+func (p123 *Pan123) log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	if p123.logger == nil || level < p123.logLevel {
+		return
+	}
+	p123.logger.Log(ctx, level, msg, keyvals...)
+}
+
+func (p123 *Pan123) logEnabled(level LogLevel) bool {
+	return p123.logger != nil && level >= p123.logLevel
+}
+
+const redacted = "***"
+
+// sensitiveKeys 需要脱敏的请求参数/请求头, 均为小写
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"accesstoken":   true,
+	"access_token":  true,
+	"clientsecret":  true,
+	"client_secret": true,
+	"sharepwd":      true,
+	"password":      true,
+}
+
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// redactQuery 脱敏接口请求的query参数
+func redactQuery(querys map[string]string) map[string]string {
+	if len(querys) == 0 {
+		return querys
+	}
+	out := make(map[string]string, len(querys))
+	for k, v := range querys {
+		if isSensitiveKey(k) {
+			v = redacted
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// redactJSON 脱敏接口请求的JSON body
+func redactJSON(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+	buf, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+	return string(buf)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if isSensitiveKey(k) {
+				out[k] = redacted
+			} else {
+				out[k] = redactValue(item)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// redactURL 脱敏预签名地址, query参数均视为签名信息
+func redactURL(rawURL string) string {
+	u, err := netUrl.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		q := u.Query()
+		keys := make([]string, 0, len(q))
+		for k := range q {
+			keys = append(keys, netUrl.QueryEscape(k)+"="+redacted)
+		}
+		sort.Strings(keys)
+		u.RawQuery = strings.Join(keys, "&")
+	}
+	return u.String()
+}
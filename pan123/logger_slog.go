@@ -0,0 +1,37 @@
+//go:build go1.21
+
+package pan123
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogLogger 将标准库的结构化日志 *slog.Logger 适配为Logger
+//
+// @param logger *slog.Logger
+//
+// @return Logger
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	l.logger.Log(ctx, slogLevel(level), msg, keyvals...)
+}
+
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,52 @@
+package pan123
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ResponseMeta 接口响应的元信息, 向123云盘反馈问题时请提供TraceID
+type ResponseMeta struct {
+	// 接口路径
+	Endpoint string
+	// 接口返回的x-traceID
+	TraceID string
+	// HTTP状态码
+	HTTPStatus int
+	// HTTP响应头
+	Header http.Header
+	// 最后一次请求的耗时(含读取响应)
+	Duration time.Duration
+	// 请求次数, 包含重试
+	Attempts int
+}
+
+type responseMetaKey struct{}
+
+// ContextWithResponseMeta 返回的ctx用于调用接口后获取响应元信息
+//
+// 调用成功后meta会被填充为最后一次接口调用的元信息; 一个方法内包含多次接口调用时(例如FileUpload), meta为最后一次调用的元信息,
+// 上传时获取分块上传地址的调用(可能并发执行)不填充meta; 如需获取每次调用的元信息请使用WithMiddleware读取Response.Meta
+//
+// @param ctx context.Context
+//
+// @param meta *ResponseMeta 用于接收元信息, 不可为nil
+//
+// @return context.Context
+func ContextWithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, meta)
+}
+
+func responseMetaFromContext(ctx context.Context) *ResponseMeta {
+	meta, _ := ctx.Value(responseMetaKey{}).(*ResponseMeta)
+	return meta
+}
+
+// withoutResponseMeta 返回不填充ResponseMeta的ctx, 用于并发的内部调用(例如分块上传), 避免并发写入调用方的meta
+func withoutResponseMeta(ctx context.Context) context.Context {
+	if responseMetaFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, responseMetaKey{}, (*ResponseMeta)(nil))
+}
@@ -0,0 +1,62 @@
+package pan123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResponseMeta(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Request-Id", "req-1")
+		_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{},"x-traceID":"trace-1"}`))
+	}))
+	defer srv.Close()
+
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithRetryPolicy(testRetryPolicy()), WithoutRateLimit())
+	var meta ResponseMeta
+	if _, err := p123.GetUserInfoContext(ContextWithResponseMeta(context.Background(), &meta)); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Endpoint != "/api/v1/user/info" || meta.TraceID != "trace-1" || meta.HTTPStatus != http.StatusOK {
+		t.Fatalf("meta = %+v", meta)
+	}
+	if meta.Header.Get("X-Request-Id") != "req-1" || meta.Attempts != 2 || meta.Duration <= 0 {
+		t.Fatalf("meta = %+v", meta)
+	}
+}
+
+func TestResponseMetaInMiddleware(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{},"x-traceID":"trace-` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+
+	var traceIDs []string
+	mw := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			resp, err := next(ctx, req)
+			if err == nil {
+				traceIDs = append(traceIDs, resp.Meta.TraceID)
+			}
+			return resp, err
+		}
+	}
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithMiddleware(mw), WithoutRateLimit())
+	if err := p123.MoveFile([]int64{1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p123.GetUserInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if len(traceIDs) != 2 || traceIDs[0] != "trace-/api/v1/file/move" || traceIDs[1] != "trace-/api/v1/user/info" {
+		t.Fatalf("traceIDs = %v", traceIDs)
+	}
+}
@@ -0,0 +1,311 @@
+package pan123
+
+import (
+	"bufio"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 耗时直方图的默认分桶, 单位为秒
+var (
+	defaultAPIDurationBuckets   = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	defaultChunkDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+)
+
+// Metrics SDK内置的指标收集器, 通过WithMetrics接入, 可被多个Pan123实例共享, 并发安全
+//
+// 收集的指标:
+//
+//	pan123_api_requests_total{endpoint,result}        JSON接口调用次数(含中间件, 不含重试), result为ok或error
+//	pan123_api_request_duration_seconds{endpoint}     JSON接口调用耗时(含重试)
+//	pan123_api_errors_total{endpoint,code}            JSON接口调用失败次数, code为SDKError.Code
+//	pan123_api_retries_total{endpoint}                JSON接口重试次数
+//	pan123_upload_chunks_total{result}                分块上传次数(含重试)
+//	pan123_upload_chunk_duration_seconds              分块上传耗时
+//	pan123_uploaded_bytes_total                       已成功上传的分块字节数
+//	pan123_downloaded_bytes_total                     接收的JSON接口响应字节数
+//	pan123_inflight_transfers{kind}                   进行中的请求数, kind为api或chunk
+//
+// Metrics实现了http.Handler, 以Prometheus文本格式输出; 也可通过Publish发布至expvar
+type Metrics struct {
+	mu       sync.Mutex
+	families []*metricFamily
+
+	apiRequests     *metricFamily
+	apiDuration     *metricFamily
+	apiErrors       *metricFamily
+	apiRetries      *metricFamily
+	chunkUploads    *metricFamily
+	chunkDuration   *metricFamily
+	uploadedBytes   *metricFamily
+	downloadedBytes *metricFamily
+	inflight        *metricFamily
+}
+
+type metricType string
+
+const (
+	metricCounter   metricType = "counter"
+	metricGauge     metricType = "gauge"
+	metricHistogram metricType = "histogram"
+)
+
+type metricFamily struct {
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+	buckets    []float64
+	series     map[string]*metricSeries
+}
+
+type metricSeries struct {
+	labelValues []string
+	// counter、gauge的值
+	value float64
+	// histogram各分桶的计数(非累计)、总和及总数
+	bucketCounts []uint64
+	sum          float64
+	count        uint64
+}
+
+// NewMetrics 创建指标收集器
+//
+// @return *Metrics
+func NewMetrics() *Metrics {
+	m := &Metrics{}
+	m.apiRequests = m.newFamily("pan123_api_requests_total", "Total number of 123pan OpenAPI calls.", metricCounter, nil, "endpoint", "result")
+	m.apiDuration = m.newFamily("pan123_api_request_duration_seconds", "Duration of 123pan OpenAPI calls including retries.", metricHistogram, defaultAPIDurationBuckets, "endpoint")
+	m.apiErrors = m.newFamily("pan123_api_errors_total", "Total number of failed 123pan OpenAPI calls by SDKError code.", metricCounter, nil, "endpoint", "code")
+	m.apiRetries = m.newFamily("pan123_api_retries_total", "Total number of 123pan OpenAPI request retries.", metricCounter, nil, "endpoint")
+	m.chunkUploads = m.newFamily("pan123_upload_chunks_total", "Total number of chunk upload attempts.", metricCounter, nil, "result")
+	m.chunkDuration = m.newFamily("pan123_upload_chunk_duration_seconds", "Duration of chunk upload attempts.", metricHistogram, defaultChunkDurationBuckets)
+	m.uploadedBytes = m.newFamily("pan123_uploaded_bytes_total", "Total bytes of successfully uploaded chunks.", metricCounter, nil)
+	m.downloadedBytes = m.newFamily("pan123_downloaded_bytes_total", "Total bytes of 123pan OpenAPI response bodies.", metricCounter, nil)
+	m.inflight = m.newFamily("pan123_inflight_transfers", "Number of in-flight requests.", metricGauge, nil, "kind")
+	return m
+}
+
+// WithMetrics 设置指标收集器, 默认不收集指标
+//
+// @param metrics *Metrics
+func WithMetrics(metrics *Metrics) Option {
+	return func(p123 *Pan123) {
+		p123.metrics = metrics
+	}
+}
+
+func (m *Metrics) newFamily(name, help string, typ metricType, buckets []float64, labelNames ...string) *metricFamily {
+	f := &metricFamily{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     map[string]*metricSeries{},
+	}
+	if len(labelNames) == 0 {
+		// 无标签的指标始终输出
+		f.seriesOf()
+	}
+	m.families = append(m.families, f)
+	return f
+}
+
+// seriesOf 获取标签值对应的序列, 调用方需持有锁
+func (f *metricFamily) seriesOf(labelValues ...string) *metricSeries {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: labelValues}
+		if f.typ == metricHistogram {
+			s.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (m *Metrics) add(f *metricFamily, delta float64, labelValues ...string) {
+	m.mu.Lock()
+	f.seriesOf(labelValues...).value += delta
+	m.mu.Unlock()
+}
+
+func (m *Metrics) observe(f *metricFamily, v float64, labelValues ...string) {
+	m.mu.Lock()
+	s := f.seriesOf(labelValues...)
+	for i, upper := range f.buckets {
+		if v <= upper {
+			s.bucketCounts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+	m.mu.Unlock()
+}
+
+// startAPICall 记录一次JSON接口调用的开始, 返回的函数在调用结束时执行
+func (m *Metrics) startAPICall(endpoint string) func(err error) {
+	if m == nil {
+		return func(error) {}
+	}
+	m.add(m.inflight, 1, "api")
+	start := time.Now()
+	return func(err error) {
+		m.add(m.inflight, -1, "api")
+		m.observe(m.apiDuration, time.Since(start).Seconds(), endpoint)
+		if err != nil {
+			m.add(m.apiRequests, 1, endpoint, "error")
+			code := SDKErrorCodeInternal
+			var sdkErr *SDKError
+			if errors.As(err, &sdkErr) {
+				code = sdkErr.Code
+			}
+			m.add(m.apiErrors, 1, endpoint, strconv.Itoa(code))
+			return
+		}
+		m.add(m.apiRequests, 1, endpoint, "ok")
+	}
+}
+
+func (m *Metrics) apiRetry(endpoint string) {
+	if m == nil {
+		return
+	}
+	m.add(m.apiRetries, 1, endpoint)
+}
+
+func (m *Metrics) apiResponseBytes(n int) {
+	if m == nil {
+		return
+	}
+	m.add(m.downloadedBytes, float64(n))
+}
+
+// startChunkUpload 记录一次分块上传的开始, 返回的函数在上传结束时执行
+func (m *Metrics) startChunkUpload() func(size int64, ok bool) {
+	if m == nil {
+		return func(int64, bool) {}
+	}
+	m.add(m.inflight, 1, "chunk")
+	start := time.Now()
+	return func(size int64, ok bool) {
+		m.add(m.inflight, -1, "chunk")
+		m.observe(m.chunkDuration, time.Since(start).Seconds())
+		if !ok {
+			m.add(m.chunkUploads, 1, "error")
+			return
+		}
+		m.add(m.chunkUploads, 1, "ok")
+		m.add(m.uploadedBytes, float64(size))
+	}
+}
+
+// ServeHTTP 以Prometheus文本格式(text/plain; version=0.0.4)输出所有指标
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	m.writeText(bw)
+	_ = bw.Flush()
+}
+
+func (m *Metrics) writeText(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, f := range m.families {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+		for _, s := range f.sortedSeries() {
+			if f.typ != metricHistogram {
+				fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labelNames, s.labelValues, "", ""), formatFloat(s.value))
+				continue
+			}
+			var cumulative uint64
+			for i, upper := range f.buckets {
+				cumulative += s.bucketCounts[i]
+				fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(f.labelNames, s.labelValues, "le", formatFloat(upper)), cumulative)
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(f.labelNames, s.labelValues, "le", "+Inf"), s.count)
+			fmt.Fprintf(w, "%s_sum%s %s\n", f.name, formatLabels(f.labelNames, s.labelValues, "", ""), formatFloat(s.sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", f.name, formatLabels(f.labelNames, s.labelValues, "", ""), s.count)
+		}
+	}
+}
+
+// sortedSeries 按标签值排序, 保证输出稳定
+func (f *metricFamily) sortedSeries() []*metricSeries {
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]*metricSeries, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, f.series[k])
+	}
+	return out
+}
+
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabelValue(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Publish 将指标以JSON格式发布至expvar(/debug/vars), 同名变量已存在时panic
+//
+// @param name string expvar变量名, 例如: pan123
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(m.snapshot))
+}
+
+// snapshot 所有指标的快照, 格式为 {指标名: {"标签=值,...": 值}}, histogram的值为count与sum
+func (m *Metrics) snapshot() interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]map[string]interface{}, len(m.families))
+	for _, f := range m.families {
+		values := make(map[string]interface{}, len(f.series))
+		for _, s := range f.series {
+			pairs := make([]string, 0, len(f.labelNames))
+			for i, name := range f.labelNames {
+				pairs = append(pairs, name+"="+s.labelValues[i])
+			}
+			key := strings.Join(pairs, ",")
+			if f.typ == metricHistogram {
+				values[key] = map[string]interface{}{"count": s.count, "sum": s.sum}
+			} else {
+				values[key] = s.value
+			}
+		}
+		out[f.name] = values
+	}
+	return out
+}
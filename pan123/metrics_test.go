@@ -0,0 +1,103 @@
+package pan123
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123test"
+)
+
+func scrapeMetrics(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	return rec.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	metrics := NewMetrics()
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithMetrics(metrics), WithRetryPolicy(policy))
+	p123.SetAccessToken("token")
+
+	// 第一次请求502, 重试后成功
+	srv.FailNext("/api/v1/user/info", 1, http.StatusBadGateway)
+	if _, err := p123.GetUserInfo(); err != nil {
+		t.Fatal(err)
+	}
+	srv.FailNextWithCode("/api/v1/file/detail", 1, pan123test.CodeNotFound, "not found")
+	if _, err := p123.GetFileDetail(1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "upload.txt")
+	if err := os.WriteFile(path, []byte("0123456789"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := p123.FileUpload(0, "upload.txt", file, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	text := scrapeMetrics(t, metrics)
+	for _, want := range []string{
+		"# TYPE pan123_api_requests_total counter",
+		`pan123_api_requests_total{endpoint="/api/v1/user/info",result="ok"} 1`,
+		`pan123_api_requests_total{endpoint="/api/v1/file/detail",result="error"} 1`,
+		`pan123_api_errors_total{endpoint="/api/v1/file/detail",code="5066"} 1`,
+		`pan123_api_retries_total{endpoint="/api/v1/user/info"} 1`,
+		"# TYPE pan123_api_request_duration_seconds histogram",
+		`pan123_api_request_duration_seconds_bucket{endpoint="/api/v1/user/info",le="+Inf"} 1`,
+		`pan123_api_request_duration_seconds_count{endpoint="/upload/v1/file/get_upload_url"} 3`,
+		`pan123_upload_chunks_total{result="ok"} 3`,
+		"pan123_upload_chunk_duration_seconds_count 3",
+		"pan123_uploaded_bytes_total 10",
+		`pan123_inflight_transfers{kind="api"} 0`,
+		`pan123_inflight_transfers{kind="chunk"} 0`,
+	} {
+		if !strings.Contains(text, want+"\n") {
+			t.Errorf("metrics missing %q\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "pan123_downloaded_bytes_total 0\n") {
+		t.Error("downloaded bytes not counted")
+	}
+
+	// 不调用Publish, 避免重复运行测试(-count)时expvar变量名重复
+	var vars map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(expvar.Func(metrics.snapshot).String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if v := vars["pan123_api_requests_total"]["endpoint=/api/v1/user/info,result=ok"]; v != float64(1) {
+		t.Errorf("expvar requests = %v", v)
+	}
+	if v := vars["pan123_uploaded_bytes_total"][""]; v != float64(10) {
+		t.Errorf("expvar uploaded bytes = %v", v)
+	}
+}
+
+func TestMetricsEscapeLabels(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.add(metrics.apiRetries, 1, "a\"b\\c\nd")
+	if text := scrapeMetrics(t, metrics); !strings.Contains(text, `pan123_api_retries_total{endpoint="a\"b\\c\nd"} 1`) {
+		t.Errorf("label not escaped:\n%s", text)
+	}
+}
@@ -0,0 +1,110 @@
+package pan123
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Request 经过中间件的JSON接口请求
+type Request struct {
+	// 接口路径, 例如: /api/v1/file/move
+	Endpoint string
+	// HTTP方法
+	Method string
+	// Query参数
+	Query map[string]string
+	// 额外的请求头, 中间件可在此注入请求头
+	Header map[string]string
+	// 请求体(编码为JSON前), GET请求为nil
+	Body map[string]interface{}
+	// 是否需要鉴权
+	WithAuth bool
+}
+
+// Response JSON接口响应
+type Response struct {
+	// 接口返回的原始data
+	Data json.RawMessage
+	// 响应元信息
+	Meta *ResponseMeta
+}
+
+// Handler 处理JSON接口请求
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware JSON接口中间件, 通过调用next将请求传递给下一层
+type Middleware func(next Handler) Handler
+
+// ChunkRequest 上传分块至预签名地址的请求
+type ChunkRequest struct {
+	// 预上传ID
+	PreuploadID string
+	// 分块序号, 从1开始
+	SliceNo int64
+	// 预签名上传地址
+	URL string
+	// 分块大小
+	Size int64
+	// 第几次尝试, 从1开始
+	Attempt int
+	// 额外的请求头
+	Header map[string]string
+	// 分块内容
+	Body io.Reader
+}
+
+// ChunkResponse 上传分块的响应
+type ChunkResponse struct {
+	// HTTP状态码
+	StatusCode int
+	// 响应头
+	Header http.Header
+}
+
+// ChunkHandler 处理分块上传请求
+type ChunkHandler func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error)
+
+// ChunkMiddleware 分块上传中间件
+type ChunkMiddleware func(next ChunkHandler) ChunkHandler
+
+// WithMiddleware 添加JSON接口中间件, 先添加的中间件位于外层, 每次调用(包括重试)只经过一次
+//
+// @param mws ...Middleware
+func WithMiddleware(mws ...Middleware) Option {
+	return func(p123 *Pan123) {
+		p123.middlewares = append(p123.middlewares, mws...)
+	}
+}
+
+// WithChunkMiddleware 添加分块上传中间件, 先添加的中间件位于外层, 每次尝试上传分块都会经过
+//
+// @param mws ...ChunkMiddleware
+func WithChunkMiddleware(mws ...ChunkMiddleware) Option {
+	return func(p123 *Pan123) {
+		p123.chunkMiddlewares = append(p123.chunkMiddlewares, mws...)
+	}
+}
+
+func chainMiddlewares(mws []Middleware, h Handler) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+func chainChunkMiddlewares(mws []ChunkMiddleware, h ChunkHandler) ChunkHandler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	r := make(map[string]string, len(headers))
+	for k, v := range headers {
+		r[k] = v
+	}
+	return r
+}
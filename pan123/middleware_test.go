@@ -0,0 +1,105 @@
+package pan123
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var gotHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Audit")
+		_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{"dirID":7}}`))
+	}))
+	defer srv.Close()
+
+	var order []string
+	var seen *Request
+	var seenData json.RawMessage
+	outer := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			order = append(order, "outer")
+			req.Header["X-Audit"] = "mkdir"
+			return next(ctx, req)
+		}
+	}
+	inner := func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			order = append(order, "inner")
+			seen = req
+			resp, err := next(ctx, req)
+			if err == nil {
+				seenData = resp.Data
+			}
+			return resp, err
+		}
+	}
+
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithMiddleware(outer, inner), WithoutRateLimit())
+	resp, err := p123.MkDir("dir", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.DirID != 7 {
+		t.Fatalf("DirID = %d", resp.DirID)
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Fatalf("order = %v", order)
+	}
+	if seen.Endpoint != "/upload/v1/file/mkdir" || seen.Method != "POST" || seen.Body["name"] != "dir" {
+		t.Fatalf("request = %+v", seen)
+	}
+	if string(seenData) != `{"dirID":7}` {
+		t.Fatalf("data = %v", seenData)
+	}
+	if gotHeader != "mkdir" {
+		t.Fatalf("X-Audit = %q", gotHeader)
+	}
+}
+
+func TestChunkMiddleware(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "upload.txt")
+	if err := os.WriteFile(path, []byte("0123456789"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var mu sync.Mutex
+	var sliceNos []int64
+	var sizes int64
+	mw := func(next ChunkHandler) ChunkHandler {
+		return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+			mu.Lock()
+			sliceNos = append(sliceNos, req.SliceNo)
+			sizes += req.Size
+			mu.Unlock()
+			return next(ctx, req)
+		}
+	}
+
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithChunkMiddleware(mw), WithoutRateLimit())
+	p123.SetAccessToken("token")
+	if _, err := p123.FileUpload(0, "upload.txt", file, 0); err != nil {
+		t.Fatal(err)
+	}
+	// sliceSize = 4, 10字节共3个分块
+	if len(sliceNos) != 3 || sliceNos[0] != 1 || sliceNos[2] != 3 {
+		t.Fatalf("sliceNos = %v", sliceNos)
+	}
+	if sizes != 10 {
+		t.Fatalf("sizes = %d", sizes)
+	}
+}
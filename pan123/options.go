@@ -0,0 +1,100 @@
+package pan123
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBaseURL   = "https://open-api.123pan.com"
+	defaultUserAgent = "123PAN-UNOFFICIAL-GO-SDK"
+	defaultPlatform  = "open_platform"
+)
+
+// Option NewPan123WithOptions的配置项
+type Option func(p123 *Pan123)
+
+// WithBaseURL 设置OpenAPI地址, 默认为 https://open-api.123pan.com
+//
+// @param baseURL string OpenAPI地址, 例如: http://127.0.0.1:8080
+func WithBaseURL(baseURL string) Option {
+	return func(p123 *Pan123) {
+		p123.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient 使用自定义的*http.Client发起请求
+//
+// 设置后WithTransport、WithConnPool及WithTimeout、WithTimeouts中作用于Transport的超时将不再生效
+//
+// @param httpCli *http.Client
+func WithHTTPClient(httpCli *http.Client) Option {
+	return func(p123 *Pan123) {
+		p123.httpCli = httpCli
+	}
+}
+
+// WithTransport 使用自定义的http.RoundTripper发起请求
+//
+// 设置后WithConnPool及WithTimeout、WithTimeouts中作用于Transport的超时将不再生效
+//
+// @param transport http.RoundTripper
+func WithTransport(transport http.RoundTripper) Option {
+	return func(p123 *Pan123) {
+		p123.transport = transport
+	}
+}
+
+// WithTimeout 设置建立TCP连接的超时时间, 即Timeouts.Dial, 默认为0(不限制)
+//
+// 其他阶段的超时请使用WithTimeouts
+//
+// @param timeout time.Duration
+func WithTimeout(timeout time.Duration) Option {
+	return func(p123 *Pan123) {
+		if timeout < 0 {
+			timeout = 0
+		}
+		p123.timeouts.Dial = timeout
+	}
+}
+
+// WithUserAgent 设置请求头User-Agent, 默认为 123PAN-UNOFFICIAL-GO-SDK
+//
+// @param userAgent string
+func WithUserAgent(userAgent string) Option {
+	return func(p123 *Pan123) {
+		p123.userAgent = userAgent
+	}
+}
+
+// WithPlatform 设置请求头Platform, 默认为 open_platform
+//
+// @param platform string
+func WithPlatform(platform string) Option {
+	return func(p123 *Pan123) {
+		p123.platform = platform
+	}
+}
+
+// WithDebug 是否开启debug, 开启后输出每次请求的详细日志(已脱敏)
+//
+// @param debug bool
+func WithDebug(debug bool) Option {
+	return func(p123 *Pan123) {
+		p123.debug = debug
+	}
+}
+
+// WithUploadConcurrency 设置单个文件上传时并发上传的分块数, 小于等于1时依次上传(默认)
+//
+// 每个并发占用一个分块大小的内存, 即总内存约为 concurrency × 分块大小;
+// 并发数较大时建议同时调大ConnPool.MaxIdleConnsPerHost
+//
+// @param concurrency int
+func WithUploadConcurrency(concurrency int) Option {
+	return func(p123 *Pan123) {
+		p123.uploadConcurrency = concurrency
+	}
+}
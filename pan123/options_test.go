@@ -0,0 +1,54 @@
+package pan123
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewPan123WithOptions(t *testing.T) {
+	var gotPath, gotUA, gotPlatform, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUA = r.Header.Get("User-Agent")
+		gotPlatform = r.Header.Get("Platform")
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{"dirID":42},"x-traceID":"t"}`))
+	}))
+	defer srv.Close()
+
+	p123 := NewPan123WithOptions(
+		WithBaseURL(srv.URL+"/"),
+		WithHTTPClient(srv.Client()),
+		WithUserAgent("test-agent"),
+		WithPlatform("test-platform"),
+	)
+	p123.SetAccessToken("token")
+
+	resp, err := p123.MkDir("dir", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.DirID != 42 {
+		t.Fatalf("DirID = %d, want 42", resp.DirID)
+	}
+	if gotPath != "/upload/v1/file/mkdir" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if gotUA != "test-agent" || gotPlatform != "test-platform" {
+		t.Fatalf("User-Agent = %q, Platform = %q", gotUA, gotPlatform)
+	}
+	if gotAuth != "Bearer token" {
+		t.Fatalf("Authorization = %q", gotAuth)
+	}
+}
+
+func TestNewPan123Defaults(t *testing.T) {
+	p123 := NewPan123(0, false)
+	if p123.baseURL != defaultBaseURL {
+		t.Fatalf("baseURL = %q", p123.baseURL)
+	}
+	if p123.httpCli == nil || p123.httpCli.Transport == nil {
+		t.Fatal("default http client not initialised")
+	}
+}
@@ -2,50 +2,113 @@ package pan123
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	netUrl "net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// Pan123 123云盘SDK实例
+//
+// Pan123 可被多个goroutine并发使用, 所有配置在创建后不可变, accessToken的读写均已加锁
 type Pan123 struct {
-	accessToken string
-	timeout     time.Duration
-	debug       bool
-
-	httpCli *http.Client
+	tokenMu            sync.RWMutex
+	accessToken        string
+	tokenSource        TokenSource
+	tokenStore         TokenStore
+	clientID           string
+	clientSecret       string
+	timeouts           Timeouts
+	connPool           ConnPool
+	streamSpool        StreamSpool
+	uploadConcurrency  int
+	uploadSessionStore UploadSessionStore
+	debug              bool
+	baseURL            string
+	userAgent          string
+	platform           string
+	logger             Logger
+	logLevel           LogLevel
+	logLevelSet        bool
+	retryPolicy        RetryPolicy
+	rateLimits         map[string]RateLimit
+	rateLimiter        *rateLimiter
+	metrics            *Metrics
+	tracer             Tracer
+
+	middlewares      []Middleware
+	chunkMiddlewares []ChunkMiddleware
+	apiHandler       Handler
+	chunkHandler     ChunkHandler
+
+	transport http.RoundTripper
+	httpCli   *http.Client
 }
 
 // NewPan123 创建123云盘SDK实例
 //
-// @param timeout time.Duration HTTP请求超时时间, 默认为0
+// @param timeout time.Duration 建立TCP连接的超时时间, 默认为0(不限制), 其他超时见WithTimeouts
 //
 // @param debug bool 是否开启debug
 //
 // @return *Pan123
 func NewPan123(timeout time.Duration, debug bool) *Pan123 {
+	return NewPan123WithOptions(WithTimeout(timeout), WithDebug(debug))
+}
+
+// NewPan123WithOptions 使用Option创建123云盘SDK实例
+//
+// @param opts ...Option 配置项, 例如: WithBaseURL、WithHTTPClient、WithTransport
+//
+// @return *Pan123
+func NewPan123WithOptions(opts ...Option) *Pan123 {
 	p123 := &Pan123{
-		timeout: timeout,
-		debug:   debug,
+		baseURL:     defaultBaseURL,
+		userAgent:   defaultUserAgent,
+		platform:    defaultPlatform,
+		connPool:    DefaultConnPool(),
+		streamSpool: DefaultStreamSpool(),
+	}
+	for _, opt := range opts {
+		opt(p123)
 	}
 
-	p123.httpCli = &http.Client{
-		Transport: &http.Transport{
-			Dial: func(network, addr string) (c net.Conn, err error) {
-				return net.DialTimeout(network, addr, timeout)
-			},
-			Proxy: http.ProxyFromEnvironment,
-		},
+	if p123.httpCli == nil {
+		if p123.transport == nil {
+			p123.transport = newDefaultTransport(p123.timeouts, p123.connPool)
+		}
+		p123.httpCli = &http.Client{
+			Transport: p123.transport,
+		}
+	}
+	if p123.logger == nil && p123.debug {
+		p123.logger = newStdoutLogger()
+	}
+	if !p123.logLevelSet {
+		p123.logLevel = LogLevelInfo
+		if p123.debug {
+			p123.logLevel = LogLevelDebug
+		}
+	}
+	p123.rateLimiter = newRateLimiter(p123.rateLimits)
+	if p123.tracer == nil {
+		p123.tracer = noopTracer{}
 	}
+	if p123.tokenSource == nil && p123.clientID != "" {
+		p123.tokenSource = p123.newClientCredentialsTokenSource()
+	}
+	p123.apiHandler = chainMiddlewares(p123.middlewares, p123.doCallApi)
+	p123.chunkHandler = chainChunkMiddlewares(p123.chunkMiddlewares, p123.doChunkUpload)
 
 	return p123
 }
@@ -54,13 +117,19 @@ func NewPan123(timeout time.Duration, debug bool) *Pan123 {
 //
 // @return string
 func (p123 *Pan123) GetAccessToken() string {
+	p123.tokenMu.RLock()
+	defer p123.tokenMu.RUnlock()
 	return p123.accessToken
 }
 
 // SetAccessToken 设置当前accessToken
 //
+// 使用WithTokenSource、WithClientCredentials时无需调用
+//
 // @param accessToken string access_token
 func (p123 *Pan123) SetAccessToken(accessToken string) {
+	p123.tokenMu.Lock()
+	defer p123.tokenMu.Unlock()
 	p123.accessToken = accessToken
 }
 
@@ -76,28 +145,28 @@ func (p123 *Pan123) SetAccessToken(accessToken string) {
 //
 // @return SDKError
 func (p123 *Pan123) RequestAccessToken(clientID, clientSecret string) (string, time.Time, error) {
+	return p123.RequestAccessTokenContext(context.Background(), clientID, clientSecret)
+}
+
+// RequestAccessTokenContext 同RequestAccessToken, 支持通过ctx控制超时与取消
+func (p123 *Pan123) RequestAccessTokenContext(ctx context.Context, clientID, clientSecret string) (string, time.Time, error) {
 	bodyData := map[string]interface{}{
 		"clientID":     clientID,
 		"clientSecret": clientSecret,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return "", time.Time{}, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/api/v1/access_token", "POST", body, map[string]string{}, false)
+	resp, err := p123.callApi(ctx, epAccessToken, bodyData, nil)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
-	var respData loginRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[loginRespData](resp.Data)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 	_accessTokenExpiredAt, err := time.Parse(time.RFC3339, respData.ExpiredAt)
 	if err != nil {
-		return "", time.Time{}, err
+		return "", time.Time{}, newKindError(ErrDecode, fmt.Sprintf("time.Parse(expiredAt) error: %s", err), err)
 	}
 
 	return respData.AccessToken, _accessTokenExpiredAt, nil
@@ -119,8 +188,19 @@ func (p123 *Pan123) RequestAccessToken(clientID, clientSecret string) (string, t
 //
 // @return SDKError
 func (p123 *Pan123) CreateShare(shareName, fileIDList, sharePwd string, shareExpire int) (*CreateShareRespData, error) {
+	return p123.CreateShareContext(context.Background(), shareName, fileIDList, sharePwd, shareExpire)
+}
+
+// CreateShareContext 同CreateShare, 支持通过ctx控制超时与取消
+func (p123 *Pan123) CreateShareContext(ctx context.Context, shareName, fileIDList, sharePwd string, shareExpire int) (*CreateShareRespData, error) {
+	if shareName == "" {
+		return nil, newValidationError("shareName", "empty")
+	}
+	if err := validateFileIDList("fileIDList", fileIDList); err != nil {
+		return nil, err
+	}
 	if shareExpire != 1 && shareExpire != 7 && shareExpire != 30 && shareExpire != 0 {
-		return nil, newSDKError(999, "shareExpire invalid", defaultTraceID)
+		return nil, newValidationError("shareExpire", "must be one of 0, 1, 7, 30, got %d", shareExpire)
 	}
 	bodyData := map[string]interface{}{
 		"shareName":   shareName,
@@ -129,22 +209,17 @@ func (p123 *Pan123) CreateShare(shareName, fileIDList, sharePwd string, shareExp
 		"shareExpire": shareExpire,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/api/v1/share/create", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epShareCreate, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData CreateShareRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[CreateShareRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // MkDir 创建目录
@@ -157,47 +232,63 @@ func (p123 *Pan123) CreateShare(shareName, fileIDList, sharePwd string, shareExp
 //
 // @return SDKError
 func (p123 *Pan123) MkDir(name string, parentID int64) (*MkDirRespData, error) {
+	return p123.MkDirContext(context.Background(), name, parentID)
+}
+
+// MkDirContext 同MkDir, 支持通过ctx控制超时与取消
+func (p123 *Pan123) MkDirContext(ctx context.Context, name string, parentID int64) (*MkDirRespData, error) {
+	if err := validateFilename("name", name); err != nil {
+		return nil, err
+	}
 	bodyData := map[string]interface{}{
 		"name":     name,
 		"parentID": parentID,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/upload/v1/file/mkdir", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epMkDir, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData MkDirRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[MkDirRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
-func (p123 *Pan123) fileUploadCreateFile(parentFileID int64, filename string, file *os.File, fileSize int64) (*fileUploadCreateFileRespData, error) {
+// fileUploadHash 计算内容的md5
+func fileUploadHash(ctx context.Context, content io.ReaderAt, fileSize int64, progress *uploadProgress) (string, error) {
 	hash := md5.New()
 	hashBuf := make([]byte, 4*1024*1024)
+	reader := io.NewSectionReader(content, 0, fileSize)
+	var hashed int64
 	for {
-		n, err := file.Read(hashBuf)
+		if err := ctx.Err(); err != nil {
+			return "", newCtxError(err)
+		}
+		n, err := reader.Read(hashBuf)
 		if err != nil && err != io.EOF {
-			return nil, newSDKError(999, fmt.Sprintf("file.Read(hashBuf) error: %s", err), defaultTraceID)
+			return "", newKindError(ErrFileIO, fmt.Sprintf("content.Read(hashBuf) error: %s", err), err)
 		}
 		if n == 0 {
 			break
 		}
 		if _, err := hash.Write(hashBuf[:n]); err != nil {
-			return nil, newSDKError(999, fmt.Sprintf("file.Read(hashBuf) error: %s", err), defaultTraceID)
+			return "", newKindError(ErrFileIO, fmt.Sprintf("content.Read(hashBuf) error: %s", err), err)
 		}
+		hashed += int64(n)
+		progress.addHashed(int64(n))
 	}
 	hashBuf = nil
-	md5Sum := fmt.Sprintf("%x", hash.Sum(nil))
-	hash.Reset()
+	if hashed != fileSize {
+		return "", newKindError(ErrFileIO, fmt.Sprintf("content size %d != %d", hashed, fileSize), io.ErrUnexpectedEOF)
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+func (p123 *Pan123) fileUploadCreateFile(ctx context.Context, parentFileID int64, filename string, md5Sum string, fileSize int64) (*fileUploadCreateFileRespData, error) {
 	bodyData := map[string]interface{}{
 		"parentFileID": parentFileID,
 		"filename":     filename,
@@ -205,176 +296,223 @@ func (p123 *Pan123) fileUploadCreateFile(parentFileID int64, filename string, fi
 		"size":         fileSize,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/upload/v1/file/create", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epUploadCreate, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData fileUploadCreateFileRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[fileUploadCreateFileRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
-	_, err = file.Seek(0, io.SeekStart)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("file.Seek(io.SeekStart) error: %s", err), defaultTraceID)
-	}
 
-	return &respData, nil
+	return respData, nil
 }
 
-func (p123 *Pan123) fileUploadGetChunkUploadUrl(preuploadID string, sliceNo int64) (*fileUploadGetChunkUploadUrlRespData, error) {
+func (p123 *Pan123) fileUploadGetChunkUploadUrl(ctx context.Context, preuploadID string, sliceNo int64) (*fileUploadGetChunkUploadUrlRespData, error) {
 	bodyData := map[string]interface{}{
 		"preuploadID": preuploadID,
 		"sliceNo":     sliceNo,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/upload/v1/file/get_upload_url", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epUploadGetURL, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData fileUploadGetChunkUploadUrlRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[fileUploadGetChunkUploadUrlRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
-func (p123 *Pan123) fileUploadChunkUpload(preuploadID string, sliceSize int64, file *os.File, retry int, cb FileUploadCallbackFunc, chunkCount int64) (*fileUploadChunkUploadRespData, error) {
-	var currFileSliceNo int64 = 1
-	chunkBuf := make([]byte, sliceSize)
-	fileSliceSizes := map[int64]int64{}
+// fileUploadChunkUpload 上传所有分块, 跳过uploaded中已上传的分块, 每个分块上传完成后调用partDone
+func (p123 *Pan123) fileUploadChunkUpload(ctx context.Context, preuploadID string, sliceSize int64, content io.ReaderAt, fileSize int64, retry int, progress *uploadProgress, chunkCount int64, uploaded map[int64]int64, partDone func(sliceNo, size int64)) (*fileUploadChunkUploadRespData, error) {
+	workers := int64(p123.uploadConcurrency)
+	if workers < 1 {
+		workers = 1
+	}
+	if remaining := chunkCount - int64(len(uploaded)); workers > remaining {
+		workers = remaining
+	}
 
-	for {
-		if currFileSliceNo > chunkCount {
+	// 任一分块失败时取消其余分块; 分块并发上传, 不填充调用方的ResponseMeta
+	workerCtx, cancel := context.WithCancel(withoutResponseMeta(ctx))
+	defer cancel()
+
+	var (
+		mu             sync.Mutex
+		firstErr       error
+		fileSliceSizes = map[int64]int64{}
+	)
+	for sliceNo, size := range uploaded {
+		fileSliceSizes[sliceNo] = size
+	}
+	setErr := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	sliceNos := make(chan int64)
+	var wg sync.WaitGroup
+	for i := int64(0); i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个worker复用一个分块大小的缓冲区
+			chunkBuf := &sliceBuffer{}
+			for sliceNo := range sliceNos {
+				n, err := p123.fileUploadSlice(workerCtx, preuploadID, sliceNo, sliceSize, content, fileSize, chunkBuf, retry, progress, chunkCount)
+				if err != nil {
+					setErr(err)
+					continue
+				}
+				mu.Lock()
+				fileSliceSizes[sliceNo] = n
+				mu.Unlock()
+				partDone(sliceNo, n)
+			}
+		}()
+	}
+
+	// 按序分发分块, FIRST_UPLOAD_CHUNK按分块序号依次通知
+dispatch:
+	for sliceNo := int64(1); sliceNo <= chunkCount; sliceNo++ {
+		if workerCtx.Err() != nil {
 			break
 		}
-		_currFileSliceNo := currFileSliceNo
-		cb(FileUploadCallbackInfo{
+		if _, ok := uploaded[sliceNo]; ok {
+			continue
+		}
+		progress.emit(FileUploadCallbackInfo{
 			Status:     FILE_UPLOAD_CALLBACK_STATUS_FIRST_UPLOAD_CHUNK,
-			ChunkID:    _currFileSliceNo,
+			ChunkID:    sliceNo,
 			ChunkCount: chunkCount,
 		})
-		// 获取块上传地址
-		getChunkUploadUrlResp, err := p123.fileUploadGetChunkUploadUrl(preuploadID, currFileSliceNo)
-		if err != nil {
-			return nil, err
+		select {
+		case sliceNos <- sliceNo:
+		case <-workerCtx.Done():
+			break dispatch
 		}
+	}
+	close(sliceNos)
+	wg.Wait()
 
-		// 读取块
-		n, err := file.Read(chunkBuf)
-		if err != nil && err != io.EOF {
-			return nil, newSDKError(999, fmt.Sprintf("file.Read(chunkBuf) error: %s", err), defaultTraceID)
-		}
-		if n == 0 && err == io.EOF {
-			return nil, newSDKError(999, fmt.Sprintf("file.Read(chunkBuf) error: io.EOF and size = 0"), defaultTraceID)
-		}
-		fileSliceSizes[currFileSliceNo] = int64(n)
-		// 在读取块内容后就对块ID进行累加
-		currFileSliceNo++
-
-		// 上传块
-		var _chunkBuf bytes.Buffer
-		_chunkBuf.Write(chunkBuf[:n])
-		nowRetry := 0
-		var retryErr error
-		for {
-			if nowRetry > retry {
-				break
-			}
-			if nowRetry != 0 {
-				cb(FileUploadCallbackInfo{
-					Status:     FILE_UPLOAD_CALLBACK_STATUS_RETRY_UPLOAD_CHUNK,
-					ChunkID:    _currFileSliceNo,
-					ChunkCount: chunkCount,
-				})
-			}
-			chunkUploadResp, err := p123.doHTTPRequest("PUT", getChunkUploadUrlResp.PresignedURL, map[string]string{}, map[string]string{}, &_chunkBuf)
-			if err != nil {
-				retryErr = newSDKError(999, fmt.Sprintf("http error: %s", err), defaultTraceID)
-				nowRetry++
-				continue
-			}
-			if chunkUploadResp == nil {
-				retryErr = newSDKError(999, "p123.doHTTPRequest nil?", defaultTraceID)
-				nowRetry++
-				continue
+	if err := ctx.Err(); err != nil {
+		return nil, newCtxError(err)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return &fileUploadChunkUploadRespData{fileSliceSizes: fileSliceSizes}, nil
+}
+
+// fileUploadSlice 获取上传地址并上传单个分块, 返回分块大小
+func (p123 *Pan123) fileUploadSlice(ctx context.Context, preuploadID string, sliceNo, sliceSize int64, content io.ReaderAt, fileSize int64, chunkBuf *sliceBuffer, retry int, progress *uploadProgress, chunkCount int64) (int64, error) {
+	// 获取块上传地址
+	getChunkUploadUrlResp, err := p123.fileUploadGetChunkUploadUrl(ctx, preuploadID, sliceNo)
+	if err != nil {
+		return 0, err
+	}
+
+	// 读取块
+	offset := (sliceNo - 1) * sliceSize
+	want := fileUploadSliceLen(sliceNo, sliceSize, fileSize)
+	n, err := content.ReadAt(chunkBuf.get(sliceSize)[:want], offset)
+	if err != nil && !(err == io.EOF && int64(n) == want) {
+		return 0, newKindError(ErrFileIO, fmt.Sprintf("content.ReadAt(chunkBuf, %d) error: %s", offset, err), err)
+	}
+
+	// 上传块
+	var retryErr error
+	for nowRetry := 0; nowRetry <= retry; nowRetry++ {
+		if nowRetry != 0 {
+			if err := sleepContext(ctx, chunkRetryPolicy.backoff(nowRetry, retryErr)); err != nil {
+				return 0, newCtxError(ctx.Err())
 			}
-			if chunkUploadResp.Body != nil {
-				_ = chunkUploadResp.Body.Close()
+			progress.emit(FileUploadCallbackInfo{
+				Status:     FILE_UPLOAD_CALLBACK_STATUS_RETRY_UPLOAD_CHUNK,
+				ChunkID:    sliceNo,
+				ChunkCount: chunkCount,
+			})
+		}
+		body := chunkBuf.body(n, progress)
+		chunkUploadResp, err := p123.uploadChunk(ctx, &ChunkRequest{
+			PreuploadID: preuploadID,
+			SliceNo:     sliceNo,
+			URL:         getChunkUploadUrlResp.PresignedURL,
+			Size:        int64(n),
+			Attempt:     nowRetry + 1,
+			Header:      map[string]string{},
+			Body:        body,
+		})
+		if err != nil {
+			body.rollback()
+			if ctx.Err() != nil {
+				// 已被取消, 无需重试
+				return 0, newCtxError(ctx.Err())
 			}
-			if chunkUploadResp.StatusCode != 204 && chunkUploadResp.StatusCode != 200 {
-				retryErr = newSDKError(999, fmt.Sprintf("http_code error: %d", chunkUploadResp.StatusCode), defaultTraceID)
-				nowRetry++
-				continue
+			var sdkError *SDKError
+			if errors.As(err, &sdkError) {
+				retryErr = err
+			} else {
+				retryErr = newTransportError(err)
 			}
-			break
+			continue
 		}
-		if retryErr != nil {
-			// 已经到了retry的次数
-			return nil, newSDKError(999, fmt.Sprintf("maxRetry, last error: %s", retryErr), defaultTraceID)
+		if chunkUploadResp.StatusCode != 204 && chunkUploadResp.StatusCode != 200 {
+			body.rollback()
+			retryErr = newHTTPStatusError(chunkUploadResp.StatusCode, chunkUploadResp.Header)
+			continue
 		}
-		_chunkBuf.Reset()
+		return int64(n), nil
 	}
 
-	return &fileUploadChunkUploadRespData{fileSliceSizes: fileSliceSizes}, nil
+	// 已经到了retry的次数
+	return 0, newKindError(ErrUploadFailed, fmt.Sprintf("slice %d maxRetry, last error: %s", sliceNo, retryErr), retryErr)
 }
 
-func (p123 *Pan123) fileUploadListUploadParts(preuploadID string) (*fileUploadListUploadPartsRespData, error) {
+func (p123 *Pan123) fileUploadListUploadParts(ctx context.Context, preuploadID string) (*fileUploadListUploadPartsRespData, error) {
 	bodyData := map[string]interface{}{
 		"preuploadID": preuploadID,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/upload/v1/file/list_upload_parts", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epUploadListParts, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData fileUploadListUploadPartsRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[fileUploadListUploadPartsRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
-func (p123 *Pan123) fileUploadUploadComplete(preuploadID string) (*fileUploadUploadCompleteRespData, error) {
+func (p123 *Pan123) fileUploadUploadComplete(ctx context.Context, preuploadID string) (*fileUploadUploadCompleteRespData, error) {
 	bodyData := map[string]interface{}{
 		"preuploadID": preuploadID,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/upload/v1/file/upload_complete", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epUploadComplete, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData fileUploadUploadCompleteRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[fileUploadUploadCompleteRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // FileUploadWithCallback 带Callback上传文件
@@ -393,83 +531,190 @@ func (p123 *Pan123) fileUploadUploadComplete(preuploadID string) (*fileUploadUpl
 //
 // @return SDKError
 func (p123 *Pan123) FileUploadWithCallback(parentFileID int64, filename string, file *os.File, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error) {
+	return p123.FileUploadWithCallbackContext(context.Background(), parentFileID, filename, file, retry, cb)
+}
+
+// FileUploadWithCallbackContext 同FileUploadWithCallback, 支持通过ctx控制超时与取消
+func (p123 *Pan123) FileUploadWithCallbackContext(ctx context.Context, parentFileID int64, filename string, file *os.File, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error) {
+	progress := newUploadProgress(cb)
+	if err := validateFilename("filename", filename); err != nil {
+		progress.done(nil, err)
+		return nil, err
+	}
+	fileInfo, err := fileUploadStat(file)
+	if err != nil {
+		progress.done(nil, err)
+		return nil, err
+	}
+
+	return p123.uploadContent(ctx, parentFileID, filename, file, fileInfo.Size(), fileInfo.ModTime(), "", retry, progress)
+}
+
+func fileUploadStat(file *os.File) (os.FileInfo, error) {
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("content.Stat error: %s", err), defaultTraceID)
+		return nil, newKindError(ErrFileIO, fmt.Sprintf("content.Stat error: %s", err), err)
 	}
 	if fileInfo.Size() <= 0 {
-		return nil, newSDKError(999, "file_size <= 0", defaultTraceID)
-	}
-	if cb == nil {
-		cb = func(_ FileUploadCallbackInfo) {}
+		return nil, newValidationError("file", "size must be greater than 0")
 	}
+	return fileInfo, nil
+}
+
+// fileUploadWithCallback 上传文件, md5Sum为空时计算content的md5, modTime为零值时表示未知
+func (p123 *Pan123) fileUploadWithCallback(ctx context.Context, parentFileID int64, filename string, content io.ReaderAt, fileSize int64, modTime time.Time, md5Sum string, retry int, progress *uploadProgress) (*FileUploadRespData, error) {
+	progress.setTotal(fileSize)
+	tracker := &uploadSessionTracker{p123: p123, store: p123.uploadSessionStore, key: UploadSessionKey(parentFileID, filename, fileSize)}
 
 	// 创建文件
-	cb(FileUploadCallbackInfo{
+	progress.emit(FileUploadCallbackInfo{
 		Status: FILE_UPLOAD_CALLBACK_STATUS_CREATE_FILE,
 	})
-	createFileResp, err := p123.fileUploadCreateFile(parentFileID, filename, file, fileInfo.Size())
-	if err != nil {
-		return nil, err
+	session := tracker.load(ctx, fileSize, modTime, md5Sum)
+	if session != nil && md5Sum == "" {
+		// 修改时间一致, 沿用会话中的md5
+		md5Sum = session.Etag
+	}
+	if md5Sum == "" {
+		var err error
+		if md5Sum, err = fileUploadHash(ctx, content, fileSize, progress); err != nil {
+			return nil, err
+		}
+		if session == nil {
+			// 修改时间未知(例如io.ReaderAt、io.ReadSeeker)或不一致时, 按md5匹配会话
+			session = tracker.load(ctx, fileSize, time.Time{}, md5Sum)
+		}
 	}
-	if createFileResp.Reuse {
-		// 秒传
-		return &FileUploadRespData{FileID: createFileResp.FileID, Reuse: true}, nil
+	uploaded := map[int64]int64{}
+	if session != nil {
+		// 续传, 以服务端已上传的分块为准
+		listUploadPartsResp, err := p123.fileUploadListUploadParts(ctx, session.PreuploadID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, newCtxError(ctx.Err())
+			}
+			// 预上传ID可能已过期, 重新创建
+			tracker.discard(ctx)
+			session = nil
+		} else {
+			session.CompletedParts = map[int64]int64{}
+			for _, v := range listUploadPartsResp.Parts {
+				_partNumber, err := strconv.ParseInt(v.PartNumber, 10, 0)
+				if err != nil {
+					continue
+				}
+				if v.Size == fileUploadSliceLen(_partNumber, session.SliceSize, fileSize) {
+					uploaded[_partNumber] = v.Size
+					session.CompletedParts[_partNumber] = v.Size
+				}
+			}
+			tracker.start(ctx, session)
+		}
 	}
+	if session == nil {
+		createFileResp, err := p123.fileUploadCreateFile(ctx, parentFileID, filename, md5Sum, fileSize)
+		if err != nil {
+			return nil, err
+		}
+		if createFileResp.Reuse {
+			// 秒传
+			return &FileUploadRespData{FileID: createFileResp.FileID, Reuse: true}, nil
+		}
+		now := time.Now()
+		session = &UploadSession{
+			PreuploadID:  createFileResp.PreuploadID,
+			SliceSize:    createFileResp.SliceSize,
+			ParentFileID: parentFileID,
+			Filename:     filename,
+			Size:         fileSize,
+			Etag:         md5Sum,
+			ModTime:      modTime,
+			CreatedAt:    now,
+		}
+		tracker.start(ctx, session)
+	}
+	preuploadID, sliceSize := session.PreuploadID, session.SliceSize
 
 	// 分块上传
-	var chunkCount int64 = fileInfo.Size() / createFileResp.SliceSize
-	if fileInfo.Size()%createFileResp.SliceSize != 0 {
+	var chunkCount int64 = fileSize / sliceSize
+	if fileSize%sliceSize != 0 {
 		chunkCount++
 	}
-	chunkUploadResp, err := p123.fileUploadChunkUpload(createFileResp.PreuploadID, createFileResp.SliceSize, file, retry, cb, chunkCount)
+	var uploadedBytes int64
+	for _, size := range uploaded {
+		uploadedBytes += size
+	}
+	progress.startSending(uploadedBytes)
+	chunkUploadResp, err := p123.fileUploadChunkUpload(ctx, preuploadID, sliceSize, content, fileSize, retry, progress, chunkCount, uploaded, func(sliceNo, size int64) {
+		tracker.partDone(ctx, sliceNo, size)
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	// 上传完毕, 进行校验
-	if createFileResp.SliceSize < fileInfo.Size() && len(chunkUploadResp.fileSliceSizes) > 1 {
-		cb(FileUploadCallbackInfo{
+	if sliceSize < fileSize && len(chunkUploadResp.fileSliceSizes) > 1 {
+		progress.emit(FileUploadCallbackInfo{
 			Status:     FILE_UPLOAD_CALLBACK_STATUS_VERIFY_CHUNK,
 			ChunkCount: chunkCount,
 		})
-		listUploadPartsResp, err := p123.fileUploadListUploadParts(createFileResp.PreuploadID)
+		listUploadPartsResp, err := p123.fileUploadListUploadParts(ctx, preuploadID)
 		if err != nil {
 			return nil, err
 		}
 		for _, v := range listUploadPartsResp.Parts {
 			_partNumber, err := strconv.ParseInt(v.PartNumber, 10, 0)
 			if err != nil {
-				return nil, newSDKError(999, fmt.Sprintf("chunk _partNumber convert error: %s", err), defaultTraceID)
+				return nil, newKindError(ErrDecode, fmt.Sprintf("chunk _partNumber convert error: %s", err), err)
 			}
 			if _v, ok := chunkUploadResp.fileSliceSizes[_partNumber]; ok {
 				if _v != v.Size {
-					return nil, newSDKError(999, fmt.Sprintf("chunk %d size %d != %d", _partNumber, _v, v.Size), defaultTraceID)
+					tracker.discard(ctx)
+					return nil, newKindError(ErrChunkMismatch, fmt.Sprintf("chunk %d size %d != %d", _partNumber, _v, v.Size), nil)
 				}
 			} else {
-				return nil, newSDKError(999, fmt.Sprintf("chunk %d not found", _partNumber), defaultTraceID)
+				tracker.discard(ctx)
+				return nil, newKindError(ErrChunkMismatch, fmt.Sprintf("chunk %d not found", _partNumber), nil)
 			}
 		}
 	}
 
 	// 通知上传完成
-	cb(FileUploadCallbackInfo{
+	progress.emit(FileUploadCallbackInfo{
 		Status: FILE_UPLOAD_CALLBACK_STATUS_REPORT_COMPLETE,
 	})
-	uploadCompleteResp, err := p123.fileUploadUploadComplete(createFileResp.PreuploadID)
+	uploadCompleteResp, err := p123.fileUploadUploadComplete(ctx, preuploadID)
 	if err != nil {
+		if errors.Is(err, ErrAPI) {
+			// 服务端拒绝合并分块, 续传无法成功
+			tracker.discard(ctx)
+		}
 		return nil, err
 	}
 	if uploadCompleteResp.Completed {
 		// 上传成功
+		tracker.discard(ctx)
 		return &FileUploadRespData{FileID: uploadCompleteResp.FileID}, nil
 	}
 	if uploadCompleteResp.Async {
 		// 需要异步查询上传结果
-		return &FileUploadRespData{PreuploadID: createFileResp.PreuploadID, Async: true}, nil
+		tracker.discard(ctx)
+		return &FileUploadRespData{PreuploadID: preuploadID, Async: true}, nil
 	}
 
-	return nil, newSDKError(999, "upload failed", defaultTraceID)
+	return nil, newKindError(ErrUploadFailed, "upload failed", nil)
+}
+
+// fileUploadSliceLen 分块的大小, 最后一个分块可能小于sliceSize
+func fileUploadSliceLen(sliceNo, sliceSize, fileSize int64) int64 {
+	offset := (sliceNo - 1) * sliceSize
+	if offset < 0 || offset >= fileSize {
+		return 0
+	}
+	if offset+sliceSize > fileSize {
+		return fileSize - offset
+	}
+	return sliceSize
 }
 
 // FileUpload 上传文件
@@ -484,7 +729,12 @@ func (p123 *Pan123) FileUploadWithCallback(parentFileID int64, filename string,
 //
 // @return SDKError
 func (p123 *Pan123) FileUpload(parentFileID int64, filename string, file *os.File, retry int) (*FileUploadRespData, error) {
-	return p123.FileUploadWithCallback(parentFileID, filename, file, retry, nil)
+	return p123.FileUploadContext(context.Background(), parentFileID, filename, file, retry)
+}
+
+// FileUploadContext 同FileUpload, 支持通过ctx控制超时与取消
+func (p123 *Pan123) FileUploadContext(ctx context.Context, parentFileID int64, filename string, file *os.File, retry int) (*FileUploadRespData, error) {
+	return p123.FileUploadWithCallbackContext(ctx, parentFileID, filename, file, retry, nil)
 }
 
 // GetUploadAsyncResult 异步轮询获取上传结果
@@ -495,26 +745,26 @@ func (p123 *Pan123) FileUpload(parentFileID int64, filename string, file *os.Fil
 //
 // @return SDKError
 func (p123 *Pan123) GetUploadAsyncResult(preuploadID string) (*UploadAsyncResultRespData, error) {
+	return p123.GetUploadAsyncResultContext(context.Background(), preuploadID)
+}
+
+// GetUploadAsyncResultContext 同GetUploadAsyncResult, 支持通过ctx控制超时与取消
+func (p123 *Pan123) GetUploadAsyncResultContext(ctx context.Context, preuploadID string) (*UploadAsyncResultRespData, error) {
 	bodyData := map[string]interface{}{
 		"preuploadID": preuploadID,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/upload/v1/file/upload_async_result", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epUploadAsyncResult, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData UploadAsyncResultRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[UploadAsyncResultRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // MoveFile 移动文件
@@ -527,16 +777,20 @@ func (p123 *Pan123) GetUploadAsyncResult(preuploadID string) (*UploadAsyncResult
 //
 // @return SDKError
 func (p123 *Pan123) MoveFile(fileIDs []int64, toParentFileID int64) error {
+	return p123.MoveFileContext(context.Background(), fileIDs, toParentFileID)
+}
+
+// MoveFileContext 同MoveFile, 支持通过ctx控制超时与取消
+func (p123 *Pan123) MoveFileContext(ctx context.Context, fileIDs []int64, toParentFileID int64) error {
+	if err := validateFileIDs("fileIDs", fileIDs); err != nil {
+		return err
+	}
 	bodyData := map[string]interface{}{
 		"fileIDs":        fileIDs,
 		"toParentFileID": toParentFileID,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	_, err = p123.callApi("/api/v1/file/move", "POST", body, map[string]string{}, true)
+	_, err := p123.callApi(ctx, epFileMove, bodyData, nil)
 
 	return err
 }
@@ -549,15 +803,19 @@ func (p123 *Pan123) MoveFile(fileIDs []int64, toParentFileID int64) error {
 //
 // @return SDKError
 func (p123 *Pan123) TrashFile(fileIDs []int64) error {
+	return p123.TrashFileContext(context.Background(), fileIDs)
+}
+
+// TrashFileContext 同TrashFile, 支持通过ctx控制超时与取消
+func (p123 *Pan123) TrashFileContext(ctx context.Context, fileIDs []int64) error {
+	if err := validateFileIDs("fileIDs", fileIDs); err != nil {
+		return err
+	}
 	bodyData := map[string]interface{}{
 		"fileIDs": fileIDs,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	_, err = p123.callApi("/api/v1/file/trash", "POST", body, map[string]string{}, true)
+	_, err := p123.callApi(ctx, epFileTrash, bodyData, nil)
 
 	return err
 }
@@ -570,15 +828,19 @@ func (p123 *Pan123) TrashFile(fileIDs []int64) error {
 //
 // @return SDKError
 func (p123 *Pan123) RecoverFile(fileIDs []int64) error {
+	return p123.RecoverFileContext(context.Background(), fileIDs)
+}
+
+// RecoverFileContext 同RecoverFile, 支持通过ctx控制超时与取消
+func (p123 *Pan123) RecoverFileContext(ctx context.Context, fileIDs []int64) error {
+	if err := validateFileIDs("fileIDs", fileIDs); err != nil {
+		return err
+	}
 	bodyData := map[string]interface{}{
 		"fileIDs": fileIDs,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	_, err = p123.callApi("/api/v1/file/recover", "POST", body, map[string]string{}, true)
+	_, err := p123.callApi(ctx, epFileRecover, bodyData, nil)
 
 	return err
 }
@@ -591,15 +853,19 @@ func (p123 *Pan123) RecoverFile(fileIDs []int64) error {
 //
 // @return SDKError
 func (p123 *Pan123) DeleteFile(fileIDs []int64) error {
+	return p123.DeleteFileContext(context.Background(), fileIDs)
+}
+
+// DeleteFileContext 同DeleteFile, 支持通过ctx控制超时与取消
+func (p123 *Pan123) DeleteFileContext(ctx context.Context, fileIDs []int64) error {
+	if err := validateFileIDs("fileIDs", fileIDs); err != nil {
+		return err
+	}
 	bodyData := map[string]interface{}{
 		"fileIDs": fileIDs,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	_, err = p123.callApi("/api/v1/file/delete", "POST", body, map[string]string{}, true)
+	_, err := p123.callApi(ctx, epFileDelete, bodyData, nil)
 
 	return err
 }
@@ -608,7 +874,7 @@ func (p123 *Pan123) DeleteFile(fileIDs []int64) error {
 //
 // @param parentFileId int64 文件夹ID，根目录传 0
 //
-// @param page int64 页码数
+// @param page int64 页码数, 从1开始
 //
 // @param limit int64 每页文件数量，最大不超过100
 //
@@ -624,11 +890,22 @@ func (p123 *Pan123) DeleteFile(fileIDs []int64) error {
 //
 // @return SDKError
 func (p123 *Pan123) GetFileList(parentFileId, page, limit int64, orderBy, orderDirection string, trashed bool, searchData string) (*GetFileListRespData, error) {
+	return p123.GetFileListContext(context.Background(), parentFileId, page, limit, orderBy, orderDirection, trashed, searchData)
+}
+
+// GetFileListContext 同GetFileList, 支持通过ctx控制超时与取消
+func (p123 *Pan123) GetFileListContext(ctx context.Context, parentFileId, page, limit int64, orderBy, orderDirection string, trashed bool, searchData string) (*GetFileListRespData, error) {
+	if err := validatePage("page", page); err != nil {
+		return nil, err
+	}
+	if err := validateLimit("limit", limit); err != nil {
+		return nil, err
+	}
 	if orderBy != "file_id" && orderBy != "size" && orderBy != "file_name" {
-		return nil, newSDKError(999, "orderBy invalid", defaultTraceID)
+		return nil, newValidationError("orderBy", "must be one of file_id, size, file_name, got %q", orderBy)
 	}
 	if orderDirection != "asc" && orderDirection != "desc" {
-		return nil, newSDKError(999, "orderDirection invalid", defaultTraceID)
+		return nil, newValidationError("orderDirection", "must be asc or desc, got %q", orderDirection)
 	}
 	querys := map[string]string{
 		"parentFileId":   strconv.FormatInt(parentFileId, 10),
@@ -644,18 +921,17 @@ func (p123 *Pan123) GetFileList(parentFileId, page, limit int64, orderBy, orderD
 		querys["trashed"] = "true"
 	}
 
-	resp, err := p123.callApi("/api/v1/file/list", "GET", nil, querys, true)
+	resp, err := p123.callApi(ctx, epFileList, nil, querys)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData GetFileListRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[GetFileListRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // GetFileListV2 获取文件列表（推荐）
@@ -674,6 +950,17 @@ func (p123 *Pan123) GetFileList(parentFileId, page, limit int64, orderBy, orderD
 //
 // @return SDKError
 func (p123 *Pan123) GetFileListV2(parentFileId, limit int64, searchData string, searchMode, lastFileId int64) (*GetFileListRespDataV2, error) {
+	return p123.GetFileListV2Context(context.Background(), parentFileId, limit, searchData, searchMode, lastFileId)
+}
+
+// GetFileListV2Context 同GetFileListV2, 支持通过ctx控制超时与取消
+func (p123 *Pan123) GetFileListV2Context(ctx context.Context, parentFileId, limit int64, searchData string, searchMode, lastFileId int64) (*GetFileListRespDataV2, error) {
+	if err := validateLimit("limit", limit); err != nil {
+		return nil, err
+	}
+	if searchMode != -1 && searchMode != 0 && searchMode != 1 {
+		return nil, newValidationError("searchMode", "must be one of -1, 0, 1, got %d", searchMode)
+	}
 	querys := map[string]string{
 		"parentFileId": strconv.FormatInt(parentFileId, 10),
 		"limit":        strconv.FormatInt(limit, 10),
@@ -688,18 +975,17 @@ func (p123 *Pan123) GetFileListV2(parentFileId, limit int64, searchData string,
 		querys["lastFileId"] = strconv.FormatInt(lastFileId, 10)
 	}
 
-	resp, err := p123.callApi("/api/v2/file/list", "GET", nil, querys, true)
+	resp, err := p123.callApi(ctx, epFileListV2, nil, querys)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData GetFileListRespDataV2
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[GetFileListRespDataV2](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // GetUserInfo 获取用户信息
@@ -708,18 +994,22 @@ func (p123 *Pan123) GetFileListV2(parentFileId, limit int64, searchData string,
 //
 // @return SDKError
 func (p123 *Pan123) GetUserInfo() (*GetUserInfoRespData, error) {
-	resp, err := p123.callApi("/api/v1/user/info", "GET", nil, map[string]string{}, true)
+	return p123.GetUserInfoContext(context.Background())
+}
+
+// GetUserInfoContext 同GetUserInfo, 支持通过ctx控制超时与取消
+func (p123 *Pan123) GetUserInfoContext(ctx context.Context) (*GetUserInfoRespData, error) {
+	resp, err := p123.callApi(ctx, epUserInfo, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData GetUserInfoRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[GetUserInfoRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // OfflineDownload 创建离线下载任务
@@ -738,6 +1028,19 @@ func (p123 *Pan123) GetUserInfo() (*GetUserInfoRespData, error) {
 //
 // @return SDKError
 func (p123 *Pan123) OfflineDownload(url, fileName, callBackUrl string, dirID int64) (*OfflineDownloadRespData, error) {
+	return p123.OfflineDownloadContext(context.Background(), url, fileName, callBackUrl, dirID)
+}
+
+// OfflineDownloadContext 同OfflineDownload, 支持通过ctx控制超时与取消
+func (p123 *Pan123) OfflineDownloadContext(ctx context.Context, url, fileName, callBackUrl string, dirID int64) (*OfflineDownloadRespData, error) {
+	if err := validateHTTPURL("url", url); err != nil {
+		return nil, err
+	}
+	if fileName != "" {
+		if err := validateFilename("fileName", fileName); err != nil {
+			return nil, err
+		}
+	}
 	bodyData := map[string]interface{}{
 		"url": url,
 	}
@@ -751,22 +1054,17 @@ func (p123 *Pan123) OfflineDownload(url, fileName, callBackUrl string, dirID int
 		bodyData["dirID"] = dirID
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("api/v1/offline/download", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epOfflineDownload, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData OfflineDownloadRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[OfflineDownloadRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // QueryDirectLinkTranscode 查询直链转码进度
@@ -777,26 +1075,26 @@ func (p123 *Pan123) OfflineDownload(url, fileName, callBackUrl string, dirID int
 //
 // @return SDKError
 func (p123 *Pan123) QueryDirectLinkTranscode(ids []int64) (*QueryDirectLinkTranscodeRespData, error) {
+	return p123.QueryDirectLinkTranscodeContext(context.Background(), ids)
+}
+
+// QueryDirectLinkTranscodeContext 同QueryDirectLinkTranscode, 支持通过ctx控制超时与取消
+func (p123 *Pan123) QueryDirectLinkTranscodeContext(ctx context.Context, ids []int64) (*QueryDirectLinkTranscodeRespData, error) {
 	bodyData := map[string]interface{}{
 		"ids": ids,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/api/v1/direct-link/queryTranscode", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epDirectLinkQueryTranscode, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData QueryDirectLinkTranscodeRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[QueryDirectLinkTranscodeRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // DoDirectLinkTranscode 发起直链转码
@@ -807,15 +1105,16 @@ func (p123 *Pan123) QueryDirectLinkTranscode(ids []int64) (*QueryDirectLinkTrans
 //
 // @return SDKError
 func (p123 *Pan123) DoDirectLinkTranscode(ids []int64) error {
+	return p123.DoDirectLinkTranscodeContext(context.Background(), ids)
+}
+
+// DoDirectLinkTranscodeContext 同DoDirectLinkTranscode, 支持通过ctx控制超时与取消
+func (p123 *Pan123) DoDirectLinkTranscodeContext(ctx context.Context, ids []int64) error {
 	bodyData := map[string]interface{}{
 		"ids": ids,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	_, err = p123.callApi("/api/v1/direct-link/doTranscode", "POST", body, map[string]string{}, true)
+	_, err := p123.callApi(ctx, epDirectLinkDoTranscode, bodyData, nil)
 
 	return err
 }
@@ -828,22 +1127,26 @@ func (p123 *Pan123) DoDirectLinkTranscode(ids []int64) error {
 //
 // @return SDKError
 func (p123 *Pan123) GetDirectLinkM3u8(fileID int64) (*GetDirectLinkM3u8RespData, error) {
+	return p123.GetDirectLinkM3u8Context(context.Background(), fileID)
+}
+
+// GetDirectLinkM3u8Context 同GetDirectLinkM3u8, 支持通过ctx控制超时与取消
+func (p123 *Pan123) GetDirectLinkM3u8Context(ctx context.Context, fileID int64) (*GetDirectLinkM3u8RespData, error) {
 	querys := map[string]string{
 		"fileID": strconv.FormatInt(fileID, 10),
 	}
 
-	resp, err := p123.callApi("/api/v1/direct-link/get/m3u8", "GET", nil, querys, true)
+	resp, err := p123.callApi(ctx, epDirectLinkM3u8, nil, querys)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData GetDirectLinkM3u8RespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[GetDirectLinkM3u8RespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // EnableDirectLink 启用直链空间
@@ -854,26 +1157,26 @@ func (p123 *Pan123) GetDirectLinkM3u8(fileID int64) (*GetDirectLinkM3u8RespData,
 //
 // @return SDKError
 func (p123 *Pan123) EnableDirectLink(fileID int64) (*EnableDirectLinkRespData, error) {
+	return p123.EnableDirectLinkContext(context.Background(), fileID)
+}
+
+// EnableDirectLinkContext 同EnableDirectLink, 支持通过ctx控制超时与取消
+func (p123 *Pan123) EnableDirectLinkContext(ctx context.Context, fileID int64) (*EnableDirectLinkRespData, error) {
 	bodyData := map[string]interface{}{
 		"fileID": fileID,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/api/v1/direct-link/enable", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epDirectLinkEnable, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData EnableDirectLinkRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[EnableDirectLinkRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // DisableDirectLink 禁用直链空间
@@ -884,26 +1187,26 @@ func (p123 *Pan123) EnableDirectLink(fileID int64) (*EnableDirectLinkRespData, e
 //
 // @return SDKError
 func (p123 *Pan123) DisableDirectLink(fileID int64) (*DisableDirectLinkRespData, error) {
+	return p123.DisableDirectLinkContext(context.Background(), fileID)
+}
+
+// DisableDirectLinkContext 同DisableDirectLink, 支持通过ctx控制超时与取消
+func (p123 *Pan123) DisableDirectLinkContext(ctx context.Context, fileID int64) (*DisableDirectLinkRespData, error) {
 	bodyData := map[string]interface{}{
 		"fileID": fileID,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	resp, err := p123.callApi("/api/v1/direct-link/disable", "POST", body, map[string]string{}, true)
+	resp, err := p123.callApi(ctx, epDirectLinkDisable, bodyData, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData DisableDirectLinkRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[DisableDirectLinkRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // GetDirectLinkUrl 获取直链链接
@@ -914,38 +1217,46 @@ func (p123 *Pan123) DisableDirectLink(fileID int64) (*DisableDirectLinkRespData,
 //
 // @return SDKError
 func (p123 *Pan123) GetDirectLinkUrl(fileID int64) (*GetDirectLinkUrlRespData, error) {
+	return p123.GetDirectLinkUrlContext(context.Background(), fileID)
+}
+
+// GetDirectLinkUrlContext 同GetDirectLinkUrl, 支持通过ctx控制超时与取消
+func (p123 *Pan123) GetDirectLinkUrlContext(ctx context.Context, fileID int64) (*GetDirectLinkUrlRespData, error) {
 	querys := map[string]string{
 		"fileID": strconv.FormatInt(fileID, 10),
 	}
-	resp, err := p123.callApi("/api/v1/direct-link/url", "GET", nil, querys, true)
+	resp, err := p123.callApi(ctx, epDirectLinkURL, nil, querys)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData GetDirectLinkUrlRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[GetDirectLinkUrlRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // RenameFile 重命名文件
 //
-// @param renameList []string 数组,每个成员的格式为 文件ID|新的文件名
+// @param renameList []string 数组,每个成员的格式为 文件ID|新的文件名, 一次最多30个
 //
 // @return SDKError
 func (p123 *Pan123) RenameFile(renameList []string) error {
+	return p123.RenameFileContext(context.Background(), renameList)
+}
+
+// RenameFileContext 同RenameFile, 支持通过ctx控制超时与取消
+func (p123 *Pan123) RenameFileContext(ctx context.Context, renameList []string) error {
+	if err := validateRenameList("renameList", renameList); err != nil {
+		return err
+	}
 	bodyData := map[string]interface{}{
 		"renameList": renameList,
 	}
 
-	body, err := json.Marshal(bodyData)
-	if err != nil {
-		return newSDKError(999, fmt.Sprintf("json.Marshal(req) error: %s", err), defaultTraceID)
-	}
-	_, err = p123.callApi("/api/v1/file/rename", "POST", body, map[string]string{}, true)
+	_, err := p123.callApi(ctx, epFileRename, bodyData, nil)
 
 	return err
 }
@@ -958,21 +1269,25 @@ func (p123 *Pan123) RenameFile(renameList []string) error {
 //
 // @return SDKError
 func (p123 *Pan123) GetFileDetail(fileID int64) (*GetFileDetailRespData, error) {
+	return p123.GetFileDetailContext(context.Background(), fileID)
+}
+
+// GetFileDetailContext 同GetFileDetail, 支持通过ctx控制超时与取消
+func (p123 *Pan123) GetFileDetailContext(ctx context.Context, fileID int64) (*GetFileDetailRespData, error) {
 	querys := map[string]string{
 		"fileID": strconv.FormatInt(fileID, 10),
 	}
-	resp, err := p123.callApi("/api/v1/file/detail", "GET", nil, querys, true)
+	resp, err := p123.callApi(ctx, epFileDetail, nil, querys)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData GetFileDetailRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[GetFileDetailRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
 // GetOfflineDownloadProcess 获取离线下载进度
@@ -983,49 +1298,196 @@ func (p123 *Pan123) GetFileDetail(fileID int64) (*GetFileDetailRespData, error)
 //
 // @return SDKError
 func (p123 *Pan123) GetOfflineDownloadProcess(taskID int64) (*GetOfflineDownloadProcessRespData, error) {
+	return p123.GetOfflineDownloadProcessContext(context.Background(), taskID)
+}
+
+// GetOfflineDownloadProcessContext 同GetOfflineDownloadProcess, 支持通过ctx控制超时与取消
+func (p123 *Pan123) GetOfflineDownloadProcessContext(ctx context.Context, taskID int64) (*GetOfflineDownloadProcessRespData, error) {
 	querys := map[string]string{
 		"taskID": strconv.FormatInt(taskID, 10),
 	}
-	resp, err := p123.callApi("/api/v1/offline/download/process", "GET", nil, querys, true)
+	resp, err := p123.callApi(ctx, epOfflineDownloadProcess, nil, querys)
 	if err != nil {
 		return nil, err
 	}
 
-	var respData GetOfflineDownloadProcessRespData
-	err = toRespData(resp.Data, &respData)
+	respData, err := decodeRespData[GetOfflineDownloadProcessRespData](resp.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &respData, nil
+	return respData, nil
 }
 
-func (p123 *Pan123) callApi(path, method string, body []byte, querys map[string]string, withAuth bool) (*callApiResp, error) {
-	headers := map[string]string{}
+func (p123 *Pan123) callApi(ctx context.Context, ep *Endpoint, bodyData map[string]interface{}, querys map[string]string) (*callApiResp, error) {
 	r := &callApiResp{}
-	accessToken := ""
-	if withAuth {
-		accessToken = p123.accessToken
+	if querys == nil {
+		querys = map[string]string{}
+	}
+	done := p123.metrics.startAPICall(ep.Path)
+	spanCtx, span := p123.tracer.Start(ctx, ep.Method+" "+ep.Path, Attr(AttrEndpoint, ep.Path), Attr(AttrHTTPMethod, ep.Method))
+	resp, err := p123.apiHandler(spanCtx, &Request{
+		Endpoint: ep.Path,
+		Method:   ep.Method,
+		Query:    querys,
+		Header:   map[string]string{},
+		Body:     bodyData,
+		WithAuth: ep.Auth,
+	})
+	done(err)
+	var meta *ResponseMeta
+	if resp != nil {
+		meta = resp.Meta
 	}
-
-	data, err := p123.doApiRequest(method, path, accessToken, querys, headers, body)
+	endAPISpan(span, meta, err)
 	if err != nil {
 		var sdkError *SDKError
 		if !errors.As(err, &sdkError) {
-			// 不应被触发
+			// 中间件返回的非SDKError
 			return nil, err
 		}
-		return nil, err
+		return nil, withEndpoint(err, ep.Path)
 	} else {
-		r.Data = data
+		r.Data = resp.Data
+		if meta := responseMetaFromContext(ctx); meta != nil && resp.Meta != nil {
+			*meta = *resp.Meta
+		}
 	}
 
 	return r, nil
 }
 
-func (p123 *Pan123) doApiRequest(method, path, accessToken string, querys map[string]string, headers map[string]string, body []byte) (map[string]interface{}, error) {
-	headers["Platform"] = "open_platform"
-	headers["User-Agent"] = "123PAN-UNOFFICIAL-GO-SDK"
+// doCallApi 中间件链的最内层Handler
+func (p123 *Pan123) doCallApi(ctx context.Context, req *Request) (*Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = json.Marshal(req.Body)
+		if err != nil {
+			return nil, newKindError(ErrValidation, fmt.Sprintf("json.Marshal(req) error: %s", err), err)
+		}
+	}
+
+	var data json.RawMessage
+	var meta *ResponseMeta
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = p123.rateLimiter.wait(ctx, req.Endpoint); err != nil {
+			break
+		}
+		data, meta, err = p123.doAuthApiRequest(ctx, req.Endpoint, req.Method, body, req.Query, req.Header, req.WithAuth, attempt)
+		if err == nil || !p123.shouldRetry(ctx, req.Method, req.Endpoint, err, attempt) {
+			break
+		}
+		backoff := p123.retryPolicy.backoff(attempt, err)
+		p123.metrics.apiRetry(req.Endpoint)
+		p123.log(ctx, LogLevelInfo, "pan123 api retry",
+			"method", req.Method, "endpoint", req.Endpoint, "attempt", attempt, "backoff", backoff, "error", err)
+		if waitErr := sleepContext(ctx, backoff); waitErr != nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, withEndpoint(err, req.Endpoint)
+	}
+
+	return &Response{Data: data, Meta: meta}, nil
+}
+
+func (p123 *Pan123) doAuthApiRequest(ctx context.Context, path, method string, body []byte, querys map[string]string, headers map[string]string, withAuth bool, attempt int) (json.RawMessage, *ResponseMeta, error) {
+	accessToken := ""
+	if withAuth {
+		var err error
+		accessToken, err = p123.getAccessToken(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	data, meta, err := p123.doApiRequest(ctx, method, path, accessToken, querys, copyHeaders(headers), body, attempt)
+	if err != nil && withAuth && p123.tokenSource != nil && isUnauthorizedError(err) {
+		// accessToken已失效, 刷新后重试一次
+		p123.tokenSource.Invalidate(accessToken)
+		accessToken, err = p123.getAccessToken(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, meta, err = p123.doApiRequest(ctx, method, path, accessToken, querys, copyHeaders(headers), body, attempt)
+	}
+
+	return data, meta, err
+}
+
+// uploadChunk 经过分块上传中间件链上传一个分块
+func (p123 *Pan123) uploadChunk(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+	done := p123.metrics.startChunkUpload()
+	ctx, span := p123.tracer.Start(ctx, "PUT chunk",
+		Attr(AttrPreuploadID, req.PreuploadID), Attr(AttrSliceNo, req.SliceNo), Attr(AttrSize, req.Size),
+		Attr(AttrAttempts, req.Attempt), Attr(AttrHTTPMethod, "PUT"))
+	defer span.End()
+
+	resp, err := p123.chunkHandler(ctx, req)
+	if err == nil && resp == nil {
+		// 中间件可能返回nil, nil
+		err = newKindError(ErrUploadFailed, fmt.Sprintf("chunk %d: chunkHandler returned nil response", req.SliceNo), nil)
+	}
+	if err != nil {
+		done(req.Size, false)
+		span.RecordError(err)
+		return nil, err
+	}
+	ok := resp.StatusCode == 200 || resp.StatusCode == 204
+	done(req.Size, ok)
+	span.SetAttributes(Attr(AttrHTTPStatusCode, resp.StatusCode))
+	if !ok {
+		span.RecordError(newHTTPStatusError(resp.StatusCode, resp.Header))
+	}
+	return resp, nil
+}
+
+// doChunkUpload 分块上传中间件链的最内层ChunkHandler
+func (p123 *Pan123) doChunkUpload(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+	headers := copyHeaders(req.Header)
+	if _, ok := headers["Content-Length"]; !ok {
+		// 中间件可能替换Body, 预签名地址不支持chunked上传
+		headers["Content-Length"] = strconv.FormatInt(req.Size, 10)
+	}
+	chunkCtx, cancel := contextWithTimeout(ctx, p123.timeouts.Chunk)
+	defer cancel()
+	start := time.Now()
+	resp, err := p123.doHTTPRequest(chunkCtx, "PUT", req.URL, map[string]string{}, headers, req.Body)
+	if err != nil {
+		var urlErr *netUrl.Error
+		if errors.As(err, &urlErr) {
+			// 错误信息中不保留预签名地址的签名
+			urlErr.URL = redactURL(urlErr.URL)
+		}
+		err = newTimeoutAwareTransportError(ctx, err, p123.timeouts.Chunk)
+		p123.log(ctx, LogLevelWarn, "pan123 chunk upload failed",
+			"url", redactURL(req.URL), "slice_no", req.SliceNo, "size", req.Size, "attempt", req.Attempt,
+			"duration", time.Since(start), "error", err)
+		return nil, err
+	}
+	if resp == nil {
+		return nil, newKindError(ErrTransport, "p123.doHTTPRequest nil?", nil)
+	}
+	if resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	level := LogLevelDebug
+	if resp.StatusCode != 200 {
+		level = LogLevelWarn
+	}
+	p123.log(ctx, level, "pan123 chunk upload",
+		"url", redactURL(req.URL), "slice_no", req.SliceNo, "size", req.Size, "attempt", req.Attempt,
+		"status", resp.StatusCode, "duration", time.Since(start))
+
+	return &ChunkResponse{StatusCode: resp.StatusCode, Header: resp.Header}, nil
+}
+
+func (p123 *Pan123) doApiRequest(ctx context.Context, method, path, accessToken string, querys map[string]string, headers map[string]string, body []byte, attempt int) (data json.RawMessage, meta *ResponseMeta, err error) {
+	headers["Platform"] = p123.platform
+	headers["User-Agent"] = p123.userAgent
 	if accessToken != "" {
 		headers["Authorization"] = "Bearer " + accessToken
 	}
@@ -1037,40 +1499,91 @@ func (p123 *Pan123) doApiRequest(method, path, accessToken string, querys map[st
 	if body != nil {
 		buf.Write(body)
 	}
-	resp, err := p123.doHTTPRequest(method, "https://open-api.123pan.com"+path, querys, headers, &buf)
+	meta = &ResponseMeta{Endpoint: path, TraceID: defaultTraceID, Attempts: attempt}
+	reqCtx, cancel := contextWithTimeout(ctx, p123.timeouts.Request)
+	defer cancel()
+	start := time.Now()
+	resp, err := p123.doHTTPRequest(reqCtx, method, p123.baseURL+path, querys, headers, &buf)
 	defer func() {
 		if resp != nil && resp.Body != nil {
 			_ = resp.Body.Close()
 		}
+		meta.Duration = time.Since(start)
+		p123.logApiRequest(ctx, method, querys, body, meta, err)
 	}()
 	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("http error: %s", err), defaultTraceID)
+		return nil, meta, newTimeoutAwareTransportError(ctx, err, p123.timeouts.Request)
 	}
 	if resp == nil {
-		return nil, newSDKError(999, "p123.doHTTPRequest nil?", defaultTraceID)
+		return nil, meta, newKindError(ErrTransport, "p123.doHTTPRequest nil?", nil)
 	}
+	meta.HTTPStatus = resp.StatusCode
+	meta.Header = resp.Header
 	if resp.StatusCode != 200 {
-		return nil, newSDKError(999, fmt.Sprintf("http_code error: %d", resp.StatusCode), defaultTraceID)
+		return nil, meta, newHTTPStatusError(resp.StatusCode, resp.Header)
 	}
 	respBuf, err := ioutil.ReadAll(resp.Body)
+	p123.metrics.apiResponseBytes(len(respBuf))
 	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("http_body read error: %s", err), defaultTraceID)
+		if ctx.Err() != nil {
+			return nil, meta, newCtxError(ctx.Err())
+		}
+		return nil, meta, newKindError(ErrTransport, fmt.Sprintf("http_body read error: %s", err), err)
 	}
 
 	var r apiHttpResp
 	err = json.Unmarshal(respBuf, &r)
 	if err != nil {
-		return nil, newSDKError(999, fmt.Sprintf("http_resp not json: %s", err), defaultTraceID)
+		return nil, meta, newKindError(ErrDecode, fmt.Sprintf("http_resp not json: %s", err), err)
+	}
+	if r.TraceID != "" {
+		meta.TraceID = r.TraceID
 	}
 	if r.Code != 0 {
 		// 接口错误响应
-		return nil, newSDKError(r.Code, r.Message, r.TraceID)
+		return nil, meta, newApiError(&r, resp)
 	}
 
-	return r.Data, nil
+	return r.Data, meta, nil
 }
 
-func (p123 *Pan123) doHTTPRequest(method, url string, querys map[string]string, headers map[string]string, body io.Reader) (resp *http.Response, err error) {
+// logApiRequest 记录接口请求日志, 成功为Debug级别, 失败为Warn级别
+func (p123 *Pan123) logApiRequest(ctx context.Context, method string, querys map[string]string, body []byte, meta *ResponseMeta, err error) {
+	level := LogLevelDebug
+	if err != nil {
+		level = LogLevelWarn
+	}
+	if !p123.logEnabled(level) {
+		return
+	}
+
+	keyvals := []interface{}{
+		"method", method, "endpoint", meta.Endpoint, "attempt", meta.Attempts,
+		"status", meta.HTTPStatus, "duration", meta.Duration,
+	}
+	traceID := meta.TraceID
+	var sdkError *SDKError
+	if errors.As(err, &sdkError) && sdkError.TraceID != defaultTraceID {
+		traceID = sdkError.TraceID
+	}
+	keyvals = append(keyvals, "trace_id", traceID)
+	if p123.logEnabled(LogLevelDebug) {
+		if len(querys) > 0 {
+			keyvals = append(keyvals, "query", redactQuery(querys))
+		}
+		if len(body) > 0 {
+			keyvals = append(keyvals, "body", redactJSON(body))
+		}
+	}
+	if err != nil {
+		keyvals = append(keyvals, "error", err)
+		p123.log(ctx, level, "pan123 api request failed", keyvals...)
+		return
+	}
+	p123.log(ctx, level, "pan123 api request", keyvals...)
+}
+
+func (p123 *Pan123) doHTTPRequest(ctx context.Context, method, url string, querys map[string]string, headers map[string]string, body io.Reader) (resp *http.Response, err error) {
 	if len(querys) > 0 {
 		_q := netUrl.Values{}
 		for k, v := range querys {
@@ -1079,7 +1592,7 @@ func (p123 *Pan123) doHTTPRequest(method, url string, querys map[string]string,
 		url = url + "?" + _q.Encode()
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -1124,10 +1637,6 @@ func (p123 *Pan123) doHTTPRequest(method, url string, querys map[string]string,
 		}
 	}
 
-	if p123.debug {
-		fmt.Printf("%+v\n", req)
-	}
-
 	resp, err = p123.httpCli.Do(req)
 	if err != nil {
 		return nil, err
@@ -1135,15 +1644,15 @@ func (p123 *Pan123) doHTTPRequest(method, url string, querys map[string]string,
 	return resp, nil
 }
 
-func toRespData(i map[string]interface{}, o interface{}) error {
-	b, err := json.Marshal(i)
-	if err != nil {
-		return newSDKError(999, fmt.Sprintf("json.Marshal(resp) error: %s", err), defaultTraceID)
+// decodeRespData 将接口返回的data直接解析为T, 避免经由map[string]interface{}导致int64精度丢失
+func decodeRespData[T any](data json.RawMessage) (*T, error) {
+	o := new(T)
+	if len(data) == 0 {
+		return o, nil
 	}
-	err = json.Unmarshal(b, o)
-	if err != nil {
-		return newSDKError(999, fmt.Sprintf("json.Unmarshal(resp) error: %s", err), defaultTraceID)
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, newKindError(ErrDecode, fmt.Sprintf("json.Unmarshal(resp) error: %s", err), err)
 	}
 
-	return nil
+	return o, nil
 }
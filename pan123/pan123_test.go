@@ -1,21 +1,45 @@
+// 默认从 testdata/sequential_pan123test.json 回放, 无需网络及凭据:
+// go test -v -run TestSequential ./pan123
+//
+// 注意: 该回放数据由进程内模拟服务pan123test录制(127.0.0.1地址、fake-preupload预上传ID等), 并非真实OpenAPI的响应,
+// 仅用于校验SDK的请求流程, 不能用于验证与真实服务的兼容性
+//
+// 重新录制回放数据(pan123test模拟服务): $Env:PAN123_RECORD="fake"
+//
+// 访问真实服务(录制结果写入 testdata/sequential_live.json, 不会覆盖上述回放数据):
 // $Env:HTTP_PROXY="http://127.0.0.1:8888"
 // $Env:HTTPS_PROXY="http://127.0.0.1:8888"
 // $Env:PAN123_ACCESS_TOKEN=""
 // $Env:PAN123_CLIENT_ID="YOUR_CLIENT_ID"
 // $Env:PAN123_CLIENT_SECRET="YOUR_CLIENT_SECRET"
-// go test -v ./pan123
+// $Env:PAN123_RECORD="1"
+// go test -v -run TestSequential ./pan123
 package pan123
 
 import (
-	"crypto/rand"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123test"
+)
+
+const (
+	// pan123test模拟服务录制的回放数据
+	pan123TestCassette = "testdata/sequential_pan123test.json"
+	// 真实服务的录制结果
+	pan123TestLiveCassette = "testdata/sequential_live.json"
+	// 模拟服务的分块大小, 使较小的测试文件也会分多块上传
+	pan123TestFakeSliceSize = 1024 * 1024
 )
 
-var pan123TestInstance = NewPan123(0, false)
+var pan123TestInstance *Pan123
+var pan123TestClientID string
+var pan123TestClientSecret string
+var pan123TestPollInterval = 2 * time.Second
 
 var pan123TestInstanceDirID int64 = 0
 var pan123TestInstanceFileID int64 = 0
@@ -24,7 +48,7 @@ var pan123TestFilePath string
 var pan123TestSmallFilePath string
 
 func _TestRequestAccessToken(t *testing.T) {
-	accessToken, accessTokenExpiredAt, err := pan123TestInstance.RequestAccessToken(os.Getenv("PAN123_CLIENT_ID"), os.Getenv("PAN123_CLIENT_SECRET"))
+	accessToken, accessTokenExpiredAt, err := pan123TestInstance.RequestAccessToken(pan123TestClientID, pan123TestClientSecret)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -65,7 +89,7 @@ func _TestUploadFile(t *testing.T) {
 				pan123TestInstanceFileID = resp2.FileID
 				break
 			}
-			time.Sleep(2 * time.Second)
+			time.Sleep(pan123TestPollInterval)
 		}
 	} else {
 		pan123TestInstanceFileID = resp.FileID
@@ -97,7 +121,7 @@ func _TestUploadSmallFile(t *testing.T) {
 				pan123TestInstanceSmallFileID = resp2.FileID
 				break
 			}
-			time.Sleep(2 * time.Second)
+			time.Sleep(pan123TestPollInterval)
 		}
 	} else {
 		pan123TestInstanceSmallFileID = resp.FileID
@@ -181,18 +205,42 @@ func _TestTrashFile(t *testing.T) {
 }
 
 func TestSequential(t *testing.T) {
+	recordEnv := os.Getenv(pan123test.RecordEnv)
+	cassette := pan123TestCassette
+	if recordEnv != "" && recordEnv != "fake" {
+		cassette = pan123TestLiveCassette
+	}
+	rec := pan123test.NewRecorder(t, cassette, pan123test.ModeFromEnv(), nil)
+	opts := []Option{WithTransport(rec)}
+	pan123TestClientID = os.Getenv("PAN123_CLIENT_ID")
+	pan123TestClientSecret = os.Getenv("PAN123_CLIENT_SECRET")
+	if recordEnv == "fake" {
+		srv := pan123test.NewServer(pan123test.WithSliceSize(pan123TestFakeSliceSize))
+		defer srv.Close()
+		opts = append(opts, WithBaseURL(srv.URL))
+		pan123TestClientID = pan123test.DefaultClientID
+		pan123TestClientSecret = pan123test.DefaultClientSecret
+	}
+	if rec.Mode() == pan123test.ModeReplay {
+		opts = append(opts, WithoutRateLimit())
+		pan123TestPollInterval = 0
+	}
+	pan123TestInstance = NewPan123WithOptions(opts...)
 	pan123TestInstance.SetAccessToken(os.Getenv("PAN123_ACCESS_TOKEN"))
 
+	// 回放时请求体需与录制时一致, 测试文件内容由固定种子生成
+	random := rand.New(rand.NewSource(123))
+
 	// 创建测试文件
 	setupTestFile := func() error {
-		// 123MB
-		const fileSize = 123 * 1024 * 1024
+		// 3MB+123B, 在模拟服务中分4块上传
+		const fileSize = 3*pan123TestFakeSliceSize + 123
 
 		exePath, _err := os.Executable()
 		if _err != nil {
 			return _err
 		}
-		pan123TestFilePath = filepath.Join(filepath.Dir(exePath), "test_123mb_file.txt")
+		pan123TestFilePath = filepath.Join(filepath.Dir(exePath), "test_3mb_file.txt")
 		testFile, _err := os.Create(pan123TestFilePath)
 		if _err != nil {
 			return _err
@@ -203,10 +251,13 @@ func TestSequential(t *testing.T) {
 		var totalWritten int64
 		for totalWritten < fileSize {
 			// 生成随机数据
-			n, _err := rand.Read(buffer)
+			n, _err := random.Read(buffer)
 			if _err != nil {
 				return _err
 			}
+			if remain := fileSize - totalWritten; int64(n) > remain {
+				n = int(remain)
+			}
 
 			// 写入文件
 			written, _err := testFile.Write(buffer[:n])
@@ -243,7 +294,7 @@ func TestSequential(t *testing.T) {
 		var totalWritten int64
 		for totalWritten < fileSize {
 			// 生成随机数据
-			n, _err := rand.Read(buffer)
+			n, _err := random.Read(buffer)
 			if _err != nil {
 				return _err
 			}
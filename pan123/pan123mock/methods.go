@@ -0,0 +1,526 @@
+package pan123mock
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123"
+)
+
+var _ pan123.Client = (*Mock)(nil)
+
+// AccessTokenResult RequestAccessToken的返回值
+type AccessTokenResult struct {
+	AccessToken string
+	ExpiredAt   time.Time
+}
+
+// RequestAccessToken 模拟使用clientID、clientSecret请求accessToken, 记录调用并返回方法名"RequestAccessToken"配置的响应
+//
+// @param clientID string client_id
+//
+// @param clientSecret string client_secret
+//
+// @return string accessToken
+//
+// @return time.Time accessToken过期时间
+//
+// @return error
+func (m *Mock) RequestAccessToken(clientID, clientSecret string) (string, time.Time, error) {
+	return m.RequestAccessTokenContext(context.Background(), clientID, clientSecret)
+}
+
+// RequestAccessTokenContext 同RequestAccessToken, 记录调用时的ctx
+func (m *Mock) RequestAccessTokenContext(ctx context.Context, clientID, clientSecret string) (string, time.Time, error) {
+	r, err := result[AccessTokenResult](m, ctx, "RequestAccessToken", clientID, clientSecret)
+	return r.AccessToken, r.ExpiredAt, err
+}
+
+// MkDir 模拟创建目录, 记录调用并返回方法名"MkDir"配置的响应
+//
+// @param name string 目录名(注:不能重名)
+//
+// @param parentID int 父目录id，创建到根目录时填写 0
+//
+// @return *pan123.MkDirRespData
+//
+// @return error
+func (m *Mock) MkDir(name string, parentID int64) (*pan123.MkDirRespData, error) {
+	return m.MkDirContext(context.Background(), name, parentID)
+}
+
+// MkDirContext 同MkDir, 记录调用时的ctx
+func (m *Mock) MkDirContext(ctx context.Context, name string, parentID int64) (*pan123.MkDirRespData, error) {
+	return result[*pan123.MkDirRespData](m, ctx, "MkDir", name, parentID)
+}
+
+// MoveFile 模拟移动文件, 记录调用并返回方法名"MoveFile"配置的响应
+//
+// @param fileIDs []int64 文件id数组
+//
+// @param toParentFileID int64 要移动到的目标文件夹id，移动到根目录时填写 0
+//
+// @return error
+func (m *Mock) MoveFile(fileIDs []int64, toParentFileID int64) error {
+	return m.MoveFileContext(context.Background(), fileIDs, toParentFileID)
+}
+
+// MoveFileContext 同MoveFile, 记录调用时的ctx
+func (m *Mock) MoveFileContext(ctx context.Context, fileIDs []int64, toParentFileID int64) error {
+	_, err := m.record(ctx, "MoveFile", fileIDs, toParentFileID)
+	return err
+}
+
+// TrashFile 模拟删除文件至回收站, 记录调用并返回方法名"TrashFile"配置的响应
+//
+// @param fileIDs []int64 文件id数组,一次性最大不能超过 100 个文件
+//
+// @return error
+func (m *Mock) TrashFile(fileIDs []int64) error {
+	return m.TrashFileContext(context.Background(), fileIDs)
+}
+
+// TrashFileContext 同TrashFile, 记录调用时的ctx
+func (m *Mock) TrashFileContext(ctx context.Context, fileIDs []int64) error {
+	_, err := m.record(ctx, "TrashFile", fileIDs)
+	return err
+}
+
+// RecoverFile 模拟从回收站恢复文件, 记录调用并返回方法名"RecoverFile"配置的响应
+//
+// @param fileIDs []int64 文件id数组,一次性最大不能超过 100 个文件
+//
+// @return error
+func (m *Mock) RecoverFile(fileIDs []int64) error {
+	return m.RecoverFileContext(context.Background(), fileIDs)
+}
+
+// RecoverFileContext 同RecoverFile, 记录调用时的ctx
+func (m *Mock) RecoverFileContext(ctx context.Context, fileIDs []int64) error {
+	_, err := m.record(ctx, "RecoverFile", fileIDs)
+	return err
+}
+
+// DeleteFile 模拟彻底删除文件, 记录调用并返回方法名"DeleteFile"配置的响应
+//
+// @param fileIDs []int64 文件id数组,一次性最大不能超过 100 个文件
+//
+// @return error
+func (m *Mock) DeleteFile(fileIDs []int64) error {
+	return m.DeleteFileContext(context.Background(), fileIDs)
+}
+
+// DeleteFileContext 同DeleteFile, 记录调用时的ctx
+func (m *Mock) DeleteFileContext(ctx context.Context, fileIDs []int64) error {
+	_, err := m.record(ctx, "DeleteFile", fileIDs)
+	return err
+}
+
+// RenameFile 模拟重命名文件, 记录调用并返回方法名"RenameFile"配置的响应
+//
+// @param renameList []string 数组,每个成员的格式为 文件ID|新的文件名, 一次最多30个
+//
+// @return error
+func (m *Mock) RenameFile(renameList []string) error {
+	return m.RenameFileContext(context.Background(), renameList)
+}
+
+// RenameFileContext 同RenameFile, 记录调用时的ctx
+func (m *Mock) RenameFileContext(ctx context.Context, renameList []string) error {
+	_, err := m.record(ctx, "RenameFile", renameList)
+	return err
+}
+
+// GetFileDetail 模拟获取文件详情, 记录调用并返回方法名"GetFileDetail"配置的响应
+//
+// @param fileID int64 文件ID
+//
+// @return *pan123.GetFileDetailRespData
+//
+// @return error
+func (m *Mock) GetFileDetail(fileID int64) (*pan123.GetFileDetailRespData, error) {
+	return m.GetFileDetailContext(context.Background(), fileID)
+}
+
+// GetFileDetailContext 同GetFileDetail, 记录调用时的ctx
+func (m *Mock) GetFileDetailContext(ctx context.Context, fileID int64) (*pan123.GetFileDetailRespData, error) {
+	return result[*pan123.GetFileDetailRespData](m, ctx, "GetFileDetail", fileID)
+}
+
+// GetFileList 模拟获取文件列表（旧）, 记录调用并返回方法名"GetFileList"配置的响应
+//
+// @param parentFileId int64 文件夹ID，根目录传 0
+//
+// @param page int64 页码数
+//
+// @param limit int64 每页文件数量，最大不超过100
+//
+// @param orderBy string 排序字段,例如:file_id、size、file_name
+//
+// @param orderDirection string 排序方向:asc、desc
+//
+// @param trashed boolean 是否查看回收站的文件
+//
+// @param searchData string 搜索关键字, 不需要时传空
+//
+// @return *pan123.GetFileListRespData
+//
+// @return error
+func (m *Mock) GetFileList(parentFileId, page, limit int64, orderBy, orderDirection string, trashed bool, searchData string) (*pan123.GetFileListRespData, error) {
+	return m.GetFileListContext(context.Background(), parentFileId, page, limit, orderBy, orderDirection, trashed, searchData)
+}
+
+// GetFileListContext 同GetFileList, 记录调用时的ctx
+func (m *Mock) GetFileListContext(ctx context.Context, parentFileId, page, limit int64, orderBy, orderDirection string, trashed bool, searchData string) (*pan123.GetFileListRespData, error) {
+	return result[*pan123.GetFileListRespData](m, ctx, "GetFileList", parentFileId, page, limit, orderBy, orderDirection, trashed, searchData)
+}
+
+// GetFileListV2 模拟获取文件列表（推荐）, 记录调用并返回方法名"GetFileListV2"配置的响应
+//
+// @param parentFileId int64 文件夹ID，根目录传 0
+//
+// @param limit int64 每页文件数量，最大不超过100
+//
+// @param searchData string 搜索关键字将无视文件夹ID参数进行全局查找, 不需要时传空
+//
+// @param searchMode int64 0-全文模糊搜索(注:将会根据搜索项分词,查找出相似的匹配项),1-精准搜索(注:精准搜索需要提供完整的文件名),不需要时传-1
+//
+// @param lastFileId int64 翻页查询时需要填写, 不需要时传-1
+//
+// @return *pan123.GetFileListRespDataV2
+//
+// @return error
+func (m *Mock) GetFileListV2(parentFileId, limit int64, searchData string, searchMode, lastFileId int64) (*pan123.GetFileListRespDataV2, error) {
+	return m.GetFileListV2Context(context.Background(), parentFileId, limit, searchData, searchMode, lastFileId)
+}
+
+// GetFileListV2Context 同GetFileListV2, 记录调用时的ctx
+func (m *Mock) GetFileListV2Context(ctx context.Context, parentFileId, limit int64, searchData string, searchMode, lastFileId int64) (*pan123.GetFileListRespDataV2, error) {
+	return result[*pan123.GetFileListRespDataV2](m, ctx, "GetFileListV2", parentFileId, limit, searchData, searchMode, lastFileId)
+}
+
+// FileUpload 模拟上传文件, 记录调用并返回方法名"FileUpload"配置的响应
+//
+// @param parentFileID int64 父目录id, 上传到根目录时填写0
+//
+// @param filename string 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><。（注：不能重名）
+//
+// @param retry int 上传单一文件块时的重试次数, 0为不重试
+//
+// @return *pan123.FileUploadRespData
+//
+// @return error
+func (m *Mock) FileUpload(parentFileID int64, filename string, file *os.File, retry int) (*pan123.FileUploadRespData, error) {
+	return m.FileUploadContext(context.Background(), parentFileID, filename, file, retry)
+}
+
+// FileUploadContext 同FileUpload, 记录调用时的ctx
+func (m *Mock) FileUploadContext(ctx context.Context, parentFileID int64, filename string, file *os.File, retry int) (*pan123.FileUploadRespData, error) {
+	return result[*pan123.FileUploadRespData](m, ctx, "FileUpload", parentFileID, filename, file, retry)
+}
+
+// FileUploadWithCallback 模拟带Callback上传文件, 记录调用并返回方法名"FileUploadWithCallback"配置的响应
+//
+// 与FileUpload记录为不同的方法, cb不为nil时, 返回前以FILE_UPLOAD_CALLBACK_STATUS_DONE调用一次
+//
+// @param parentFileID int64 父目录id, 上传到根目录时填写0
+//
+// @param filename string 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><。（注：不能重名）
+//
+// @param file *os.File 要上传的文件句柄
+//
+// @param retry int 上传单一文件块时的重试次数, 0为不重试
+//
+// @param cb FileUploadCallbackFunc Callback
+//
+// @return *pan123.FileUploadRespData
+//
+// @return error
+func (m *Mock) FileUploadWithCallback(parentFileID int64, filename string, file *os.File, retry int, cb pan123.FileUploadCallbackFunc) (*pan123.FileUploadRespData, error) {
+	return m.FileUploadWithCallbackContext(context.Background(), parentFileID, filename, file, retry, cb)
+}
+
+// FileUploadWithCallbackContext 同FileUploadWithCallback, 记录调用时的ctx
+func (m *Mock) FileUploadWithCallbackContext(ctx context.Context, parentFileID int64, filename string, file *os.File, retry int, cb pan123.FileUploadCallbackFunc) (*pan123.FileUploadRespData, error) {
+	return uploadResult(m, ctx, "FileUploadWithCallback", cb, parentFileID, filename, file, retry, cb)
+}
+
+// FileUploadFromReaderAt 模拟从io.ReaderAt上传文件, 记录调用并返回方法名"FileUploadFromReaderAt"配置的响应
+//
+// cb不为nil时, 返回前以FILE_UPLOAD_CALLBACK_STATUS_DONE调用一次
+//
+// @param parentFileID int64 父目录id, 上传到根目录时填写0
+//
+// @param filename string 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><。（注：不能重名）
+//
+// @param r io.ReaderAt 要上传的内容, 上传期间内容不可变更
+//
+// @param size int64 内容大小, 需大于0
+//
+// @param retry int 上传单一文件块时的重试次数, 0为不重试
+//
+// @param cb FileUploadCallbackFunc Callback, 可为nil
+//
+// @return *pan123.FileUploadRespData
+//
+// @return error
+func (m *Mock) FileUploadFromReaderAt(parentFileID int64, filename string, r io.ReaderAt, size int64, retry int, cb pan123.FileUploadCallbackFunc) (*pan123.FileUploadRespData, error) {
+	return m.FileUploadFromReaderAtContext(context.Background(), parentFileID, filename, r, size, retry, cb)
+}
+
+// FileUploadFromReaderAtContext 同FileUploadFromReaderAt, 记录调用时的ctx
+func (m *Mock) FileUploadFromReaderAtContext(ctx context.Context, parentFileID int64, filename string, r io.ReaderAt, size int64, retry int, cb pan123.FileUploadCallbackFunc) (*pan123.FileUploadRespData, error) {
+	return uploadResult(m, ctx, "FileUploadFromReaderAt", cb, parentFileID, filename, r, size, retry, cb)
+}
+
+// FileUploadFromReadSeeker 模拟从io.ReadSeeker上传文件, 记录调用并返回方法名"FileUploadFromReadSeeker"配置的响应
+//
+// cb不为nil时, 返回前以FILE_UPLOAD_CALLBACK_STATUS_DONE调用一次
+//
+// @param parentFileID int64 父目录id, 上传到根目录时填写0
+//
+// @param filename string 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><。（注：不能重名）
+//
+// @param r io.ReadSeeker 要上传的内容
+//
+// @param retry int 上传单一文件块时的重试次数, 0为不重试
+//
+// @param cb FileUploadCallbackFunc Callback, 可为nil
+//
+// @return *pan123.FileUploadRespData
+//
+// @return error
+func (m *Mock) FileUploadFromReadSeeker(parentFileID int64, filename string, r io.ReadSeeker, retry int, cb pan123.FileUploadCallbackFunc) (*pan123.FileUploadRespData, error) {
+	return m.FileUploadFromReadSeekerContext(context.Background(), parentFileID, filename, r, retry, cb)
+}
+
+// FileUploadFromReadSeekerContext 同FileUploadFromReadSeeker, 记录调用时的ctx
+func (m *Mock) FileUploadFromReadSeekerContext(ctx context.Context, parentFileID int64, filename string, r io.ReadSeeker, retry int, cb pan123.FileUploadCallbackFunc) (*pan123.FileUploadRespData, error) {
+	return uploadResult(m, ctx, "FileUploadFromReadSeeker", cb, parentFileID, filename, r, retry, cb)
+}
+
+// UploadStream 模拟上传长度未知的内容, 记录调用并返回方法名"UploadStream"配置的响应
+//
+// cb不为nil时, 返回前以FILE_UPLOAD_CALLBACK_STATUS_DONE调用一次, 不读取r
+//
+// @param parentFileID int64 父目录id, 上传到根目录时填写0
+//
+// @param filename string 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><。（注：不能重名）
+//
+// @param r io.Reader 要上传的内容, 读取至io.EOF
+//
+// @param retry int 上传单一文件块时的重试次数, 0为不重试
+//
+// @param cb FileUploadCallbackFunc Callback, 可为nil
+//
+// @return *pan123.FileUploadRespData
+//
+// @return error
+func (m *Mock) UploadStream(parentFileID int64, filename string, r io.Reader, retry int, cb pan123.FileUploadCallbackFunc) (*pan123.FileUploadRespData, error) {
+	return m.UploadStreamContext(context.Background(), parentFileID, filename, r, retry, cb)
+}
+
+// UploadStreamContext 同UploadStream, 记录调用时的ctx
+func (m *Mock) UploadStreamContext(ctx context.Context, parentFileID int64, filename string, r io.Reader, retry int, cb pan123.FileUploadCallbackFunc) (*pan123.FileUploadRespData, error) {
+	return uploadResult(m, ctx, "UploadStream", cb, parentFileID, filename, r, retry, cb)
+}
+
+// GetUploadAsyncResult 模拟异步轮询获取上传结果, 记录调用并返回方法名"GetUploadAsyncResult"配置的响应
+//
+// @param preuploadID string 预上传ID
+//
+// @return *pan123.UploadAsyncResultRespData
+//
+// @return error
+func (m *Mock) GetUploadAsyncResult(preuploadID string) (*pan123.UploadAsyncResultRespData, error) {
+	return m.GetUploadAsyncResultContext(context.Background(), preuploadID)
+}
+
+// GetUploadAsyncResultContext 同GetUploadAsyncResult, 记录调用时的ctx
+func (m *Mock) GetUploadAsyncResultContext(ctx context.Context, preuploadID string) (*pan123.UploadAsyncResultRespData, error) {
+	return result[*pan123.UploadAsyncResultRespData](m, ctx, "GetUploadAsyncResult", preuploadID)
+}
+
+// EnableDirectLink 模拟启用直链空间, 记录调用并返回方法名"EnableDirectLink"配置的响应
+//
+// @param fileID int64 启用直链空间的文件夹的fileID
+//
+// @return *pan123.EnableDirectLinkRespData
+//
+// @return error
+func (m *Mock) EnableDirectLink(fileID int64) (*pan123.EnableDirectLinkRespData, error) {
+	return m.EnableDirectLinkContext(context.Background(), fileID)
+}
+
+// EnableDirectLinkContext 同EnableDirectLink, 记录调用时的ctx
+func (m *Mock) EnableDirectLinkContext(ctx context.Context, fileID int64) (*pan123.EnableDirectLinkRespData, error) {
+	return result[*pan123.EnableDirectLinkRespData](m, ctx, "EnableDirectLink", fileID)
+}
+
+// DisableDirectLink 模拟禁用直链空间, 记录调用并返回方法名"DisableDirectLink"配置的响应
+//
+// @param fileID int64 禁用直链空间的文件夹的fileID
+//
+// @return *pan123.DisableDirectLinkRespData
+//
+// @return error
+func (m *Mock) DisableDirectLink(fileID int64) (*pan123.DisableDirectLinkRespData, error) {
+	return m.DisableDirectLinkContext(context.Background(), fileID)
+}
+
+// DisableDirectLinkContext 同DisableDirectLink, 记录调用时的ctx
+func (m *Mock) DisableDirectLinkContext(ctx context.Context, fileID int64) (*pan123.DisableDirectLinkRespData, error) {
+	return result[*pan123.DisableDirectLinkRespData](m, ctx, "DisableDirectLink", fileID)
+}
+
+// GetDirectLinkUrl 模拟获取直链链接, 记录调用并返回方法名"GetDirectLinkUrl"配置的响应
+//
+// @param fileID int64 需要获取直链链接的文件的fileID
+//
+// @return *pan123.GetDirectLinkUrlRespData
+//
+// @return error
+func (m *Mock) GetDirectLinkUrl(fileID int64) (*pan123.GetDirectLinkUrlRespData, error) {
+	return m.GetDirectLinkUrlContext(context.Background(), fileID)
+}
+
+// GetDirectLinkUrlContext 同GetDirectLinkUrl, 记录调用时的ctx
+func (m *Mock) GetDirectLinkUrlContext(ctx context.Context, fileID int64) (*pan123.GetDirectLinkUrlRespData, error) {
+	return result[*pan123.GetDirectLinkUrlRespData](m, ctx, "GetDirectLinkUrl", fileID)
+}
+
+// QueryDirectLinkTranscode 模拟查询直链转码进度, 记录调用并返回方法名"QueryDirectLinkTranscode"配置的响应
+//
+// @param ids []int64 视频文件ID列表
+//
+// @return *pan123.QueryDirectLinkTranscodeRespData
+//
+// @return error
+func (m *Mock) QueryDirectLinkTranscode(ids []int64) (*pan123.QueryDirectLinkTranscodeRespData, error) {
+	return m.QueryDirectLinkTranscodeContext(context.Background(), ids)
+}
+
+// QueryDirectLinkTranscodeContext 同QueryDirectLinkTranscode, 记录调用时的ctx
+func (m *Mock) QueryDirectLinkTranscodeContext(ctx context.Context, ids []int64) (*pan123.QueryDirectLinkTranscodeRespData, error) {
+	return result[*pan123.QueryDirectLinkTranscodeRespData](m, ctx, "QueryDirectLinkTranscode", ids)
+}
+
+// DoDirectLinkTranscode 模拟发起直链转码, 记录调用并返回方法名"DoDirectLinkTranscode"配置的响应
+//
+// @param ids []int64 需要转码的文件ID列表
+//
+// @return error
+func (m *Mock) DoDirectLinkTranscode(ids []int64) error {
+	return m.DoDirectLinkTranscodeContext(context.Background(), ids)
+}
+
+// DoDirectLinkTranscodeContext 同DoDirectLinkTranscode, 记录调用时的ctx
+func (m *Mock) DoDirectLinkTranscodeContext(ctx context.Context, ids []int64) error {
+	_, err := m.record(ctx, "DoDirectLinkTranscode", ids)
+	return err
+}
+
+// GetDirectLinkM3u8 模拟获取直链转码链接, 记录调用并返回方法名"GetDirectLinkM3u8"配置的响应
+//
+// @param fileID int64 文件ID
+//
+// @return *pan123.GetDirectLinkM3u8RespData
+//
+// @return error
+func (m *Mock) GetDirectLinkM3u8(fileID int64) (*pan123.GetDirectLinkM3u8RespData, error) {
+	return m.GetDirectLinkM3u8Context(context.Background(), fileID)
+}
+
+// GetDirectLinkM3u8Context 同GetDirectLinkM3u8, 记录调用时的ctx
+func (m *Mock) GetDirectLinkM3u8Context(ctx context.Context, fileID int64) (*pan123.GetDirectLinkM3u8RespData, error) {
+	return result[*pan123.GetDirectLinkM3u8RespData](m, ctx, "GetDirectLinkM3u8", fileID)
+}
+
+// CreateShare 模拟创建分享链接, 记录调用并返回方法名"CreateShare"配置的响应
+//
+// @param shareName string 分享链接
+//
+// @param fileIDList string 分享文件ID列表, 以逗号分割, 最大只支持拼接100个文件ID, 示例:1,2,3
+//
+// @param sharePwd string 分享链接提取码, 可为空
+//
+// @param shareExpire int 分享链接有效期天数, 1 -> 1天、7 -> 7天、30 -> 30天、0 -> 永久
+//
+// @return *pan123.CreateShareRespData
+//
+// @return error
+func (m *Mock) CreateShare(shareName, fileIDList, sharePwd string, shareExpire int) (*pan123.CreateShareRespData, error) {
+	return m.CreateShareContext(context.Background(), shareName, fileIDList, sharePwd, shareExpire)
+}
+
+// CreateShareContext 同CreateShare, 记录调用时的ctx
+func (m *Mock) CreateShareContext(ctx context.Context, shareName, fileIDList, sharePwd string, shareExpire int) (*pan123.CreateShareRespData, error) {
+	return result[*pan123.CreateShareRespData](m, ctx, "CreateShare", shareName, fileIDList, sharePwd, shareExpire)
+}
+
+// OfflineDownload 模拟创建离线下载任务, 记录调用并返回方法名"OfflineDownload"配置的响应
+//
+// @param url string 下载资源地址(http/https)
+//
+// @param fileName string 自定义文件名称
+//
+// @param callBackUrl string 回调地址, 回调内容请参考: https://123yunpan.yuque.com/org-wiki-123yunpan-muaork/cr6ced/wn77piehmp9t8ut4#jf5bZ
+//
+// @param dirID int64 下载到的指定目录ID, 不支持下载到根目录, 传0会下载到名为"来自:离线下载"的目录中
+//
+// @return *pan123.OfflineDownloadRespData
+//
+// @return error
+func (m *Mock) OfflineDownload(url, fileName, callBackUrl string, dirID int64) (*pan123.OfflineDownloadRespData, error) {
+	return m.OfflineDownloadContext(context.Background(), url, fileName, callBackUrl, dirID)
+}
+
+// OfflineDownloadContext 同OfflineDownload, 记录调用时的ctx
+func (m *Mock) OfflineDownloadContext(ctx context.Context, url, fileName, callBackUrl string, dirID int64) (*pan123.OfflineDownloadRespData, error) {
+	return result[*pan123.OfflineDownloadRespData](m, ctx, "OfflineDownload", url, fileName, callBackUrl, dirID)
+}
+
+// GetOfflineDownloadProcess 模拟获取离线下载进度, 记录调用并返回方法名"GetOfflineDownloadProcess"配置的响应
+//
+// @param taskID int64 离线下载任务ID
+//
+// @return *pan123.GetOfflineDownloadProcessRespData
+//
+// @return error
+func (m *Mock) GetOfflineDownloadProcess(taskID int64) (*pan123.GetOfflineDownloadProcessRespData, error) {
+	return m.GetOfflineDownloadProcessContext(context.Background(), taskID)
+}
+
+// GetOfflineDownloadProcessContext 同GetOfflineDownloadProcess, 记录调用时的ctx
+func (m *Mock) GetOfflineDownloadProcessContext(ctx context.Context, taskID int64) (*pan123.GetOfflineDownloadProcessRespData, error) {
+	return result[*pan123.GetOfflineDownloadProcessRespData](m, ctx, "GetOfflineDownloadProcess", taskID)
+}
+
+// GetUserInfo 模拟获取用户信息, 记录调用并返回方法名"GetUserInfo"配置的响应
+//
+// @return *pan123.GetUserInfoRespData
+//
+// @return error
+func (m *Mock) GetUserInfo() (*pan123.GetUserInfoRespData, error) {
+	return m.GetUserInfoContext(context.Background())
+}
+
+// GetUserInfoContext 同GetUserInfo, 记录调用时的ctx
+func (m *Mock) GetUserInfoContext(ctx context.Context) (*pan123.GetUserInfoRespData, error) {
+	return result[*pan123.GetUserInfoRespData](m, ctx, "GetUserInfo")
+}
+
+// uploadResult 记录上传调用, cb不为nil时以FILE_UPLOAD_CALLBACK_STATUS_DONE调用一次, 与Pan123一致
+func uploadResult(m *Mock, ctx context.Context, method string, cb pan123.FileUploadCallbackFunc, args ...interface{}) (*pan123.FileUploadRespData, error) {
+	resp, err := result[*pan123.FileUploadRespData](m, ctx, method, args...)
+	if cb != nil {
+		info := pan123.FileUploadCallbackInfo{Status: pan123.FILE_UPLOAD_CALLBACK_STATUS_DONE, Err: err}
+		if err == nil {
+			info.Result = resp
+		}
+		cb(info)
+	}
+	return resp, err
+}
@@ -0,0 +1,231 @@
+// Package pan123mock 提供 pan123.Client 的模拟实现, 用于在不访问网络的情况下测试依赖SDK的业务代码
+//
+//	m := pan123mock.New()
+//	m.Return("MkDir", &pan123.MkDirRespData{DirID: 1}, nil)
+//	m.Return("MoveFile", nil, errors.New("boom"))
+//
+//	svc := NewService(m) // 依赖 pan123.Client
+//	...
+//	calls := m.CallsTo("MkDir")
+//
+// 带Context与不带Context的同名方法(例如MkDir与MkDirContext)共用同一个方法名"MkDir"
+package pan123mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+var (
+	// ErrNotConfigured 方法未配置任何响应
+	ErrNotConfigured = errors.New("pan123mock: no response configured")
+	// ErrTypeMismatch 配置的返回值类型与方法的返回值类型不一致
+	ErrTypeMismatch = errors.New("pan123mock: result type mismatch")
+)
+
+// Call 一次方法调用
+type Call struct {
+	// 方法名, 不含Context后缀, 例如: MkDir
+	Method string
+	// 调用时的ctx, 不带Context的方法为context.Background()
+	Ctx context.Context
+	// 除ctx外的参数, 按声明顺序
+	Args []interface{}
+}
+
+// Result 方法的响应
+type Result struct {
+	// 返回值, 类型需与方法的第一个返回值一致(例如: *pan123.MkDirRespData), 仅返回error的方法忽略该值
+	Value interface{}
+	Err   error
+}
+
+// HandlerFunc 根据调用动态生成响应, 返回值要求同Result
+type HandlerFunc func(call Call) (interface{}, error)
+
+// Mock pan123.Client 的模拟实现, 可被多个goroutine并发使用
+//
+// 响应的优先级: Handle设置的HandlerFunc > Script/Return按顺序设置的响应 > Default设置的默认响应;
+// 均未设置时返回零值及ErrNotConfigured; 返回值类型与方法不一致时返回零值及ErrTypeMismatch
+type Mock struct {
+	t           testing.TB
+	mu          sync.Mutex
+	accessToken string
+	calls       []Call
+	handlers    map[string]HandlerFunc
+	scripts     map[string][]Result
+	defaults    map[string]Result
+}
+
+// New 创建Mock
+//
+// @return *Mock
+func New() *Mock {
+	return &Mock{
+		handlers: map[string]HandlerFunc{},
+		scripts:  map[string][]Result{},
+		defaults: map[string]Result{},
+	}
+}
+
+// NewWithT 创建Mock, 配置的返回值类型与方法不一致时通过t.Errorf报告测试失败
+//
+// @param t testing.TB
+//
+// @return *Mock
+func NewWithT(t testing.TB) *Mock {
+	m := New()
+	m.t = t
+	return m
+}
+
+// Script 按顺序设置方法接下来若干次调用的响应
+//
+// @param method string 方法名, 例如: MkDir
+//
+// @param results ...Result
+//
+// @return *Mock
+func (m *Mock) Script(method string, results ...Result) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scripts[method] = append(m.scripts[method], results...)
+	return m
+}
+
+// Return 设置方法下一次调用的响应, 等同于 Script(method, Result{value, err})
+//
+// @param method string 方法名, 例如: MkDir
+//
+// @param value interface{} 返回值
+//
+// @param err error
+//
+// @return *Mock
+func (m *Mock) Return(method string, value interface{}, err error) *Mock {
+	return m.Script(method, Result{Value: value, Err: err})
+}
+
+// Default 设置方法的默认响应, 在Script设置的响应用尽后使用
+//
+// @param method string 方法名, 例如: MkDir
+//
+// @param value interface{} 返回值
+//
+// @param err error
+//
+// @return *Mock
+func (m *Mock) Default(method string, value interface{}, err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaults[method] = Result{Value: value, Err: err}
+	return m
+}
+
+// Handle 设置方法的HandlerFunc
+//
+// @param method string 方法名, 例如: MkDir
+//
+// @param h HandlerFunc
+//
+// @return *Mock
+func (m *Mock) Handle(method string, h HandlerFunc) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[method] = h
+	return m
+}
+
+// Calls 所有调用记录, 按调用顺序
+//
+// @return []Call
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo 指定方法的调用记录
+//
+// @param method string 方法名, 例如: MkDir
+//
+// @return []Call
+func (m *Mock) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset 清空调用记录及所有响应配置
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	m.handlers = map[string]HandlerFunc{}
+	m.scripts = map[string][]Result{}
+	m.defaults = map[string]Result{}
+}
+
+// GetAccessToken 返回SetAccessToken设置的accessToken
+func (m *Mock) GetAccessToken() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.accessToken
+}
+
+// SetAccessToken 保存accessToken, 不记录调用
+func (m *Mock) SetAccessToken(accessToken string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accessToken = accessToken
+}
+
+// record 记录调用并返回配置的响应
+func (m *Mock) record(ctx context.Context, method string, args ...interface{}) (interface{}, error) {
+	call := Call{Method: method, Ctx: ctx, Args: args}
+
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	h, ok := m.handlers[method]
+	if !ok {
+		var r Result
+		if script := m.scripts[method]; len(script) > 0 {
+			r, m.scripts[method] = script[0], script[1:]
+		} else if r, ok = m.defaults[method]; !ok {
+			r = Result{Err: ErrNotConfigured}
+		}
+		m.mu.Unlock()
+		return r.Value, r.Err
+	}
+	m.mu.Unlock()
+
+	// HandlerFunc可能再次调用Mock, 不持有锁
+	return h(call)
+}
+
+// result 记录调用, 并将响应转换为方法的返回值类型
+func result[T any](m *Mock, ctx context.Context, method string, args ...interface{}) (T, error) {
+	var zero T
+	value, err := m.record(ctx, method, args...)
+	if value == nil {
+		return zero, err
+	}
+	v, ok := value.(T)
+	if !ok {
+		mismatch := fmt.Errorf("%w: %s expects %T, got %T", ErrTypeMismatch, method, zero, value)
+		if m.t != nil {
+			m.t.Errorf("%s", mismatch)
+		}
+		return zero, mismatch
+	}
+	return v, err
+}
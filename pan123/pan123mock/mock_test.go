@@ -0,0 +1,159 @@
+package pan123mock_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123"
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123mock"
+)
+
+// ensureDir 依赖pan123.Client的业务代码示例
+func ensureDir(ctx context.Context, c pan123.Client, name string) (int64, error) {
+	dir, err := c.MkDirContext(ctx, name, 0)
+	if err != nil {
+		return 0, err
+	}
+	return dir.DirID, nil
+}
+
+type ctxKey struct{}
+
+func TestMockReturn(t *testing.T) {
+	m := pan123mock.New()
+	m.Return("MkDir", &pan123.MkDirRespData{DirID: 42}, nil)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	dirID, err := ensureDir(ctx, m, "photos")
+	if err != nil || dirID != 42 {
+		t.Fatalf("ensureDir = %d, %v", dirID, err)
+	}
+
+	calls := m.CallsTo("MkDir")
+	if len(calls) != 1 {
+		t.Fatalf("calls = %d", len(calls))
+	}
+	if calls[0].Ctx.Value(ctxKey{}) != "v" {
+		t.Errorf("ctx not recorded")
+	}
+	if calls[0].Args[0] != "photos" || calls[0].Args[1] != int64(0) {
+		t.Errorf("args = %v", calls[0].Args)
+	}
+
+	// 响应已用尽
+	if _, err := m.MkDir("photos", 0); !errors.Is(err, pan123mock.ErrNotConfigured) {
+		t.Errorf("err = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestMockScriptAndDefault(t *testing.T) {
+	boom := errors.New("boom")
+	m := pan123mock.New()
+	m.Script("MoveFile", pan123mock.Result{Err: boom}, pan123mock.Result{})
+	m.Default("MoveFile", nil, pan123.ErrRateLimited)
+
+	for i, want := range []error{boom, nil, pan123.ErrRateLimited, pan123.ErrRateLimited} {
+		if err := m.MoveFile([]int64{1}, 2); !errors.Is(err, want) {
+			t.Errorf("call %d: err = %v, want %v", i, err, want)
+		}
+	}
+	if n := len(m.Calls()); n != 4 {
+		t.Errorf("calls = %d", n)
+	}
+
+	m.Reset()
+	if n := len(m.Calls()); n != 0 {
+		t.Errorf("calls after reset = %d", n)
+	}
+	if err := m.MoveFile(nil, 0); !errors.Is(err, pan123mock.ErrNotConfigured) {
+		t.Errorf("err after reset = %v", err)
+	}
+}
+
+func TestMockHandle(t *testing.T) {
+	m := pan123mock.New()
+	m.Handle("GetFileDetail", func(call pan123mock.Call) (interface{}, error) {
+		return &pan123.GetFileDetailRespData{FileID: call.Args[0].(int64)}, nil
+	})
+	expiredAt := time.Now().Add(time.Hour)
+	m.Return("RequestAccessToken", pan123mock.AccessTokenResult{AccessToken: "token", ExpiredAt: expiredAt}, nil)
+
+	var wg sync.WaitGroup
+	for i := int64(1); i <= 8; i++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			detail, err := m.GetFileDetail(id)
+			if err != nil || detail.FileID != id {
+				t.Errorf("GetFileDetail(%d) = %+v, %v", id, detail, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	token, exp, err := m.RequestAccessToken("id", "secret")
+	if err != nil || token != "token" || !exp.Equal(expiredAt) {
+		t.Errorf("RequestAccessToken = %s, %s, %v", token, exp, err)
+	}
+	m.SetAccessToken(token)
+	if m.GetAccessToken() != "token" {
+		t.Errorf("GetAccessToken = %s", m.GetAccessToken())
+	}
+}
+
+// recordingTB 记录Errorf调用
+type recordingTB struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+}
+
+func (tb *recordingTB) Errorf(format string, args ...interface{}) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockTypeMismatch(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	m := pan123mock.NewWithT(tb)
+	m.Return("MkDir", &pan123.GetFileDetailRespData{}, nil)
+
+	dir, err := m.MkDir("photos", 0)
+	if dir != nil || !errors.Is(err, pan123mock.ErrTypeMismatch) {
+		t.Fatalf("MkDir = %+v, %v, want ErrTypeMismatch", dir, err)
+	}
+	if len(tb.errors) != 1 {
+		t.Errorf("reported errors = %v", tb.errors)
+	}
+}
+
+func TestMockUploadCallback(t *testing.T) {
+	m := pan123mock.New()
+	want := &pan123.FileUploadRespData{FileID: 1}
+	m.Return("FileUploadFromReaderAt", want, nil)
+	m.Return("UploadStream", nil, pan123.ErrUploadFailed)
+
+	var infos []pan123.FileUploadCallbackInfo
+	cb := func(info pan123.FileUploadCallbackInfo) { infos = append(infos, info) }
+	if _, err := m.FileUploadFromReaderAt(0, "a.txt", strings.NewReader("a"), 1, 0, cb); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.UploadStream(0, "b.txt", strings.NewReader("b"), 0, cb); !errors.Is(err, pan123.ErrUploadFailed) {
+		t.Fatalf("err = %v, want ErrUploadFailed", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("infos = %+v", infos)
+	}
+	if infos[0].Status != pan123.FILE_UPLOAD_CALLBACK_STATUS_DONE || infos[0].Result != want || infos[0].Err != nil {
+		t.Errorf("infos[0] = %+v", infos[0])
+	}
+	if infos[1].Status != pan123.FILE_UPLOAD_CALLBACK_STATUS_DONE || infos[1].Result != nil || !errors.Is(infos[1].Err, pan123.ErrUploadFailed) {
+		t.Errorf("infos[1] = %+v", infos[1])
+	}
+}
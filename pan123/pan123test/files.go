@@ -0,0 +1,587 @@
+package pan123test
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 单次批量操作的文件数量上限
+const maxBatchFileIDs = 100
+
+type node struct {
+	id       int64
+	parentID int64
+	name     string
+	dir      bool
+	content  []byte
+	etag     string
+	trashed  bool
+	createAt time.Time
+
+	// 目录是否启用直链空间
+	directLink bool
+	// 直链转码状态
+	transcode transcodeState
+}
+
+// File 模拟服务中的文件或目录
+type File struct {
+	FileID       int64
+	ParentFileID int64
+	Filename     string
+	// 0-文件 1-文件夹
+	Type     int
+	Size     int64
+	Etag     string
+	Trashed  bool
+	CreateAt time.Time
+}
+
+func (n *node) file() File {
+	f := File{
+		FileID:       n.id,
+		ParentFileID: n.parentID,
+		Filename:     n.name,
+		Size:         int64(len(n.content)),
+		Etag:         n.etag,
+		Trashed:      n.trashed,
+		CreateAt:     n.createAt,
+	}
+	if n.dir {
+		f.Type = 1
+	}
+	return f
+}
+
+func (n *node) fileType() int {
+	if n.dir {
+		return 1
+	}
+	return 0
+}
+
+// category 文件分类, 0-未知 1-音频 2-视频 3-图片
+func (n *node) category() int {
+	switch strings.ToLower(path.Ext(n.name)) {
+	case ".mp3", ".flac", ".wav", ".aac":
+		return 1
+	case ".mp4", ".mkv", ".avi", ".mov":
+		return 2
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return 3
+	}
+	return 0
+}
+
+func (n *node) contentType() string {
+	switch n.category() {
+	case 1:
+		return "audio"
+	case 2:
+		return "video"
+	case 3:
+		return "image"
+	}
+	return "application/octet-stream"
+}
+
+// AddDir 创建目录
+//
+// @param parentID int64 父目录ID, 根目录为RootID
+//
+// @param name string 目录名
+//
+// @return int64 目录ID
+func (s *Server) AddDir(parentID int64, name string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addNode(parentID, name, true, nil).id
+}
+
+// AddFile 创建文件
+//
+// @param parentID int64 父目录ID, 根目录为RootID
+//
+// @param name string 文件名
+//
+// @param content []byte 文件内容
+//
+// @return int64 文件ID
+func (s *Server) AddFile(parentID int64, name string, content []byte) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addNode(parentID, name, false, content).id
+}
+
+// File 获取文件或目录信息, 已彻底删除的文件返回false
+//
+// @param fileID int64
+//
+// @return File
+//
+// @return bool
+func (s *Server) File(fileID int64) (File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.files[fileID]
+	if !ok || n.id == RootID {
+		return File{}, false
+	}
+	return n.file(), true
+}
+
+// FileContent 获取文件内容
+//
+// @param fileID int64
+//
+// @return []byte
+//
+// @return bool
+func (s *Server) FileContent(fileID int64) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.files[fileID]
+	if !ok || n.dir {
+		return nil, false
+	}
+	return append([]byte(nil), n.content...), true
+}
+
+// Lookup 按文件名查找目录下未在回收站中的文件或目录
+//
+// @param parentID int64 父目录ID
+//
+// @param name string 文件名
+//
+// @return File
+//
+// @return bool
+func (s *Server) Lookup(parentID int64, name string) (File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, n := range s.children(parentID) {
+		if n.name == name {
+			return n.file(), true
+		}
+	}
+	return File{}, false
+}
+
+func (s *Server) addNode(parentID int64, name string, dir bool, content []byte) *node {
+	n := &node{
+		id:       s.newID(),
+		parentID: parentID,
+		name:     name,
+		dir:      dir,
+		createAt: time.Now(),
+	}
+	if !dir {
+		n.content = append([]byte(nil), content...)
+		n.etag = md5Hex(n.content)
+	}
+	s.files[n.id] = n
+	return n
+}
+
+// children 目录下未在回收站中的文件, 按文件ID排序
+func (s *Server) children(parentID int64) []*node {
+	var nodes []*node
+	for _, n := range s.files {
+		if n.id != RootID && n.parentID == parentID && !n.trashed {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].id < nodes[j].id })
+	return nodes
+}
+
+func (s *Server) dir(dirID int64) (*node, *apiError) {
+	n, ok := s.files[dirID]
+	if !ok || n.trashed {
+		return nil, errNotFound(dirID)
+	}
+	if !n.dir {
+		return nil, errBadRequest("%d 不是文件夹", dirID)
+	}
+	return n, nil
+}
+
+func (s *Server) file(fileID int64) (*node, *apiError) {
+	n, ok := s.files[fileID]
+	if !ok || n.id == RootID {
+		return nil, errNotFound(fileID)
+	}
+	return n, nil
+}
+
+// isAncestor ancestorID是否为fileID自身或其上级目录
+func (s *Server) isAncestor(ancestorID, fileID int64) bool {
+	for id := fileID; ; {
+		if id == ancestorID {
+			return true
+		}
+		n, ok := s.files[id]
+		if !ok || id == RootID {
+			return false
+		}
+		id = n.parentID
+	}
+}
+
+func (s *Server) batchFiles(fileIDs []int64) ([]*node, *apiError) {
+	if len(fileIDs) == 0 || len(fileIDs) > maxBatchFileIDs {
+		return nil, errBadRequest("fileIDs数量需在1-%d之间", maxBatchFileIDs)
+	}
+	nodes := make([]*node, 0, len(fileIDs))
+	for _, fileID := range fileIDs {
+		n, apiErr := s.file(fileID)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+func queryInt(r *http.Request, key string, def int64) (int64, *apiError) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, errBadRequest("%s参数错误: %s", key, v)
+	}
+	return i, nil
+}
+
+func (s *Server) handleMkDir(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		Name     string `json:"name"`
+		ParentID int64  `json:"parentID"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if req.Name == "" {
+		return nil, errBadRequest("name不能为空")
+	}
+	if _, apiErr := s.dir(req.ParentID); apiErr != nil {
+		return nil, apiErr
+	}
+	for _, n := range s.children(req.ParentID) {
+		if n.dir && n.name == req.Name {
+			return nil, errBadRequest("该目录下已经有同名文件夹,无法进行创建")
+		}
+	}
+
+	n := s.addNode(req.ParentID, req.Name, true, nil)
+	return map[string]interface{}{"dirID": n.id}, nil
+}
+
+func (s *Server) handleFileList(r *http.Request) (interface{}, *apiError) {
+	q := r.URL.Query()
+	parentID, apiErr := queryInt(r, "parentFileId", RootID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	page, apiErr := queryInt(r, "page", 1)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	limit, apiErr := queryInt(r, "limit", 100)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if page < 1 || limit < 1 || limit > 100 {
+		return nil, errBadRequest("page或limit参数错误")
+	}
+
+	var nodes []*node
+	switch {
+	case q.Get("trashed") == "true":
+		for _, n := range s.files {
+			if n.trashed {
+				nodes = append(nodes, n)
+			}
+		}
+	case q.Get("searchData") != "":
+		for _, n := range s.files {
+			if n.id != RootID && !n.trashed && strings.Contains(n.name, q.Get("searchData")) {
+				nodes = append(nodes, n)
+			}
+		}
+	default:
+		nodes = s.children(parentID)
+	}
+
+	var less func(a, b *node) bool
+	switch q.Get("orderBy") {
+	case "file_id", "":
+		less = func(a, b *node) bool { return a.id < b.id }
+	case "size":
+		less = func(a, b *node) bool { return len(a.content) < len(b.content) }
+	case "file_name":
+		less = func(a, b *node) bool { return a.name < b.name }
+	default:
+		return nil, errBadRequest("orderBy参数错误")
+	}
+	desc := q.Get("orderDirection") == "desc"
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if desc {
+			return less(nodes[j], nodes[i])
+		}
+		return less(nodes[i], nodes[j])
+	})
+
+	total := len(nodes)
+	start := int((page - 1) * limit)
+	if start > len(nodes) {
+		start = len(nodes)
+	}
+	end := start + int(limit)
+	if end > len(nodes) {
+		end = len(nodes)
+	}
+	fileList := make([]map[string]interface{}, 0, end-start)
+	for _, n := range nodes[start:end] {
+		parentName := ""
+		if parent, ok := s.files[n.parentID]; ok {
+			parentName = parent.name
+		}
+		fileList = append(fileList, map[string]interface{}{
+			"fileID":       n.id,
+			"filename":     n.name,
+			"type":         n.fileType(),
+			"size":         len(n.content),
+			"etag":         n.etag,
+			"status":       0,
+			"parentFileID": n.parentID,
+			"parentName":   parentName,
+			"category":     n.category(),
+			"contentType":  n.contentType(),
+		})
+	}
+	return map[string]interface{}{"total": total, "fileList": fileList}, nil
+}
+
+func (s *Server) handleFileListV2(r *http.Request) (interface{}, *apiError) {
+	q := r.URL.Query()
+	parentID, apiErr := queryInt(r, "parentFileId", RootID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	limit, apiErr := queryInt(r, "limit", 100)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if limit < 1 || limit > 100 {
+		return nil, errBadRequest("limit参数错误")
+	}
+	searchMode, apiErr := queryInt(r, "searchMode", 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	lastFileID, apiErr := queryInt(r, "lastFileId", 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var nodes []*node
+	if searchData := q.Get("searchData"); searchData != "" {
+		// 搜索时忽略parentFileId, 0-模糊搜索 1-精准搜索
+		for _, n := range s.files {
+			if n.id == RootID || n.trashed {
+				continue
+			}
+			if (searchMode == 1 && n.name == searchData) || (searchMode != 1 && strings.Contains(n.name, searchData)) {
+				nodes = append(nodes, n)
+			}
+		}
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].id < nodes[j].id })
+	} else {
+		nodes = s.children(parentID)
+	}
+
+	fileList := make([]map[string]interface{}, 0, limit)
+	next := int64(-1)
+	for _, n := range nodes {
+		if n.id <= lastFileID {
+			continue
+		}
+		if int64(len(fileList)) == limit {
+			next = fileList[len(fileList)-1]["fileID"].(int64)
+			break
+		}
+		fileList = append(fileList, map[string]interface{}{
+			"fileID":       n.id,
+			"filename":     n.name,
+			"type":         n.fileType(),
+			"size":         len(n.content),
+			"etag":         n.etag,
+			"status":       0,
+			"parentFileID": n.parentID,
+			"category":     n.category(),
+		})
+	}
+	return map[string]interface{}{"lastFileId": next, "fileList": fileList}, nil
+}
+
+func (s *Server) handleMove(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		FileIDs        []int64 `json:"fileIDs"`
+		ToParentFileID int64   `json:"toParentFileID"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	nodes, apiErr := s.batchFiles(req.FileIDs)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if _, apiErr := s.dir(req.ToParentFileID); apiErr != nil {
+		return nil, apiErr
+	}
+	for _, n := range nodes {
+		if n.dir && s.isAncestor(n.id, req.ToParentFileID) {
+			return nil, errBadRequest("不能将文件夹移动到自身或其子目录下")
+		}
+	}
+
+	for _, n := range nodes {
+		n.parentID = req.ToParentFileID
+	}
+	return nil, nil
+}
+
+type fileIDsReq struct {
+	FileIDs []int64 `json:"fileIDs"`
+}
+
+func (s *Server) handleTrash(r *http.Request) (interface{}, *apiError) {
+	var req fileIDsReq
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	nodes, apiErr := s.batchFiles(req.FileIDs)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, n := range nodes {
+		n.trashed = true
+	}
+	return nil, nil
+}
+
+func (s *Server) handleRecover(r *http.Request) (interface{}, *apiError) {
+	var req fileIDsReq
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	nodes, apiErr := s.batchFiles(req.FileIDs)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, n := range nodes {
+		n.trashed = false
+		if parent, ok := s.files[n.parentID]; !ok || parent.trashed {
+			// 原目录已不存在时恢复至根目录
+			n.parentID = RootID
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) handleDelete(r *http.Request) (interface{}, *apiError) {
+	var req fileIDsReq
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	nodes, apiErr := s.batchFiles(req.FileIDs)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, n := range nodes {
+		if !n.trashed {
+			return nil, errBadRequest("文件 %d 不在回收站中, 无法彻底删除", n.id)
+		}
+	}
+
+	for _, n := range nodes {
+		for id := range s.files {
+			if id != RootID && s.isAncestor(n.id, id) {
+				delete(s.files, id)
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) handleRename(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		RenameList []string `json:"renameList"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if len(req.RenameList) == 0 || len(req.RenameList) > 30 {
+		return nil, errBadRequest("renameList数量需在1-30之间")
+	}
+
+	renames := map[*node]string{}
+	for _, item := range req.RenameList {
+		parts := strings.SplitN(item, "|", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, errBadRequest("renameList格式错误: %s", item)
+		}
+		fileID, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, errBadRequest("renameList格式错误: %s", item)
+		}
+		n, apiErr := s.file(fileID)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		renames[n] = parts[1]
+	}
+	for n, name := range renames {
+		n.name = name
+	}
+	return nil, nil
+}
+
+func (s *Server) handleDetail(r *http.Request) (interface{}, *apiError) {
+	fileID, apiErr := queryInt(r, "fileID", 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	n, apiErr := s.file(fileID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	parentName := ""
+	if parent, ok := s.files[n.parentID]; ok {
+		parentName = parent.name
+	}
+	trashed := 0
+	if n.trashed {
+		trashed = 1
+	}
+	return map[string]interface{}{
+		"fileID":       n.id,
+		"filename":     n.name,
+		"type":         n.fileType(),
+		"size":         len(n.content),
+		"etag":         n.etag,
+		"status":       0,
+		"parentFileID": n.parentID,
+		"parentName":   parentName,
+		"createAt":     n.createAt.Format("2006-01-02 15:04:05"),
+		"trashed":      trashed,
+	}, nil
+}
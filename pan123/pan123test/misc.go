@@ -0,0 +1,300 @@
+package pan123test
+
+import (
+	"fmt"
+	"net/http"
+	netUrl "net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Share 分享链接
+type Share struct {
+	ShareID   int64
+	ShareKey  string
+	ShareName string
+	SharePwd  string
+	// 有效期天数, 0为永久
+	ShareExpire int
+	FileIDs     []int64
+}
+
+// Share 根据分享码获取分享链接
+//
+// @param shareKey string 分享码
+//
+// @return Share
+//
+// @return bool
+func (s *Server) Share(shareKey string) (Share, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	share, ok := s.shares[shareKey]
+	if !ok {
+		return Share{}, false
+	}
+	_share := *share
+	_share.FileIDs = append([]int64(nil), share.FileIDs...)
+	return _share, true
+}
+
+func (s *Server) handleShareCreate(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		ShareName   string `json:"shareName"`
+		FileIDList  string `json:"fileIDList"`
+		SharePwd    string `json:"sharePwd"`
+		ShareExpire int    `json:"shareExpire"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if req.ShareName == "" {
+		return nil, errBadRequest("shareName不能为空")
+	}
+	if req.ShareExpire != 0 && req.ShareExpire != 1 && req.ShareExpire != 7 && req.ShareExpire != 30 {
+		return nil, errBadRequest("shareExpire参数错误")
+	}
+	var fileIDs []int64
+	for _, v := range strings.Split(req.FileIDList, ",") {
+		fileID, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, errBadRequest("fileIDList格式错误: %s", req.FileIDList)
+		}
+		if n, apiErr := s.file(fileID); apiErr != nil {
+			return nil, apiErr
+		} else if n.trashed {
+			return nil, errNotFound(fileID)
+		}
+		fileIDs = append(fileIDs, fileID)
+	}
+	if len(fileIDs) > maxBatchFileIDs {
+		return nil, errBadRequest("fileIDList数量不能超过%d", maxBatchFileIDs)
+	}
+
+	share := &Share{
+		ShareID:     s.newID(),
+		ShareName:   req.ShareName,
+		SharePwd:    req.SharePwd,
+		ShareExpire: req.ShareExpire,
+		FileIDs:     fileIDs,
+	}
+	share.ShareKey = fmt.Sprintf("fake-share-%d", share.ShareID)
+	s.shares[share.ShareKey] = share
+	return map[string]interface{}{"shareID": share.ShareID, "shareKey": share.ShareKey}, nil
+}
+
+type fileIDReq struct {
+	FileID int64 `json:"fileID"`
+}
+
+func (s *Server) handleDirectLinkEnable(r *http.Request) (interface{}, *apiError) {
+	var req fileIDReq
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	n, apiErr := s.dir(req.FileID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	n.directLink = true
+	return map[string]interface{}{"filename": n.name}, nil
+}
+
+func (s *Server) handleDirectLinkDisable(r *http.Request) (interface{}, *apiError) {
+	var req fileIDReq
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	n, apiErr := s.dir(req.FileID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	n.directLink = false
+	return map[string]interface{}{"filename": n.name}, nil
+}
+
+// directLinkPath 文件在直链空间中的路径, 不在已启用直链空间的目录下时返回false
+func (s *Server) directLinkPath(n *node) (string, bool) {
+	names := []string{n.name}
+	for id := n.parentID; id != RootID; {
+		parent, ok := s.files[id]
+		if !ok {
+			return "", false
+		}
+		names = append([]string{parent.name}, names...)
+		if parent.directLink {
+			return strings.Join(names, "/"), true
+		}
+		id = parent.parentID
+	}
+	return "", false
+}
+
+func (s *Server) handleDirectLinkURL(r *http.Request) (interface{}, *apiError) {
+	fileID, apiErr := queryInt(r, "fileID", 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	n, apiErr := s.file(fileID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if n.dir {
+		return nil, errBadRequest("%d 不是文件", fileID)
+	}
+	p, ok := s.directLinkPath(n)
+	if !ok {
+		return nil, errBadRequest("文件 %d 不在直链空间中", fileID)
+	}
+	return map[string]interface{}{"url": s.URL + "/direct-link/" + (&netUrl.URL{Path: p}).EscapedPath()}, nil
+}
+
+type transcodeState int
+
+const (
+	transcodeNone transcodeState = iota
+	transcodeRunning
+	transcodeSuccess
+)
+
+type idsReq struct {
+	IDs []int64 `json:"ids"`
+}
+
+func (s *Server) handleQueryTranscode(r *http.Request) (interface{}, *apiError) {
+	var req idsReq
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+
+	noneList, success, running := []int64{}, []int64{}, []int64{}
+	errorList := []map[string]interface{}{}
+	for _, id := range req.IDs {
+		n, ok := s.files[id]
+		if !ok || n.dir {
+			errorList = append(errorList, map[string]interface{}{"id": []int64{id}, "errorReason": "文件不存在或不是视频文件"})
+			continue
+		}
+		switch n.transcode {
+		case transcodeNone:
+			noneList = append(noneList, id)
+		case transcodeRunning:
+			running = append(running, id)
+			// 下一次查询时转码完成
+			n.transcode = transcodeSuccess
+		case transcodeSuccess:
+			success = append(success, id)
+		}
+	}
+	return map[string]interface{}{
+		"noneList":  noneList,
+		"errorList": errorList,
+		"success":   success,
+		"running":   running,
+	}, nil
+}
+
+func (s *Server) handleDoTranscode(r *http.Request) (interface{}, *apiError) {
+	var req idsReq
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	var nodes []*node
+	for _, id := range req.IDs {
+		n, apiErr := s.file(id)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if _, ok := s.directLinkPath(n); n.dir || !ok {
+			return nil, errBadRequest("文件 %d 不在直链空间中", id)
+		}
+		nodes = append(nodes, n)
+	}
+	for _, n := range nodes {
+		if n.transcode == transcodeNone {
+			n.transcode = transcodeRunning
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) handleM3u8(r *http.Request) (interface{}, *apiError) {
+	fileID, apiErr := queryInt(r, "fileID", 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	n, apiErr := s.file(fileID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if n.transcode != transcodeSuccess {
+		return nil, errBadRequest("文件 %d 未完成转码", fileID)
+	}
+	list := []map[string]interface{}{}
+	for _, resolution := range []string{"1080p", "720p", "480p"} {
+		list = append(list, map[string]interface{}{
+			"resolutions": resolution,
+			"address":     fmt.Sprintf("%s/direct-link/m3u8/%d/%s.m3u8", s.URL, fileID, resolution),
+		})
+	}
+	return map[string]interface{}{"list": list}, nil
+}
+
+type offlineTask struct {
+	id       int64
+	url      string
+	fileName string
+	dirID    int64
+	process  float64
+	// 0-进行中 1-下载失败 2-下载成功 3-重试中
+	status int
+}
+
+func (s *Server) handleOfflineDownload(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		URL         string `json:"url"`
+		FileName    string `json:"fileName"`
+		CallBackUrl string `json:"callBackUrl"`
+		DirID       int64  `json:"dirID"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	u, err := netUrl.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, errBadRequest("url仅支持http/https: %s", req.URL)
+	}
+	if _, apiErr := s.dir(req.DirID); apiErr != nil {
+		return nil, apiErr
+	}
+	if req.FileName == "" {
+		req.FileName = path.Base(u.Path)
+	}
+
+	task := &offlineTask{id: s.newID(), url: req.URL, fileName: req.FileName, dirID: req.DirID}
+	s.offlineTasks[task.id] = task
+	return map[string]interface{}{"taskID": task.id, "status": task.status}, nil
+}
+
+// handleOfflineProcess 每次查询进度增加50%, 下载完成后在目标目录下创建文件, 内容为下载地址
+func (s *Server) handleOfflineProcess(r *http.Request) (interface{}, *apiError) {
+	taskID, apiErr := queryInt(r, "taskID", 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	task, ok := s.offlineTasks[taskID]
+	if !ok {
+		return nil, errBadRequest("taskID不存在: %d", taskID)
+	}
+
+	if task.status == 0 {
+		task.process += 50
+		if task.process >= 100 {
+			task.process = 100
+			task.status = 2
+			s.addNode(task.dirID, task.fileName, false, []byte(task.url))
+		}
+	}
+	return map[string]interface{}{"process": task.process, "status": task.status}, nil
+}
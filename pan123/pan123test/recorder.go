@@ -0,0 +1,361 @@
+package pan123test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	netUrl "net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Mode Recorder的工作模式
+type Mode int
+
+const (
+	// ModeReplay 从cassette文件回放, 不访问网络
+	ModeReplay Mode = iota
+	// ModeRecord 转发请求并录制到cassette文件
+	ModeRecord
+)
+
+// RecordEnv 设置为非空时ModeFromEnv返回ModeRecord
+const RecordEnv = "PAN123_RECORD"
+
+// ModeFromEnv 根据环境变量PAN123_RECORD选择工作模式
+//
+// @return Mode
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+const redacted = "***"
+
+// sensitiveKeys cassette中需要脱敏的JSON字段及query参数, 均为小写
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"accesstoken":   true,
+	"access_token":  true,
+	"clientid":      true,
+	"clientsecret":  true,
+	"client_secret": true,
+	"sharepwd":      true,
+	"password":      true,
+}
+
+// presignedURLKeys 值为预签名地址的JSON字段, 均为小写
+var presignedURLKeys = map[string]bool{
+	"presignedurl": true,
+}
+
+// recordedHeaders 录制的响应头
+var recordedHeaders = []string{"Content-Type", "ETag", "Retry-After"}
+
+// Cassette 录制的请求与响应
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction 一次请求及其响应
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+
+	used bool
+}
+
+// RecordedRequest 已脱敏的请求, 回放时按方法、路径与query、body匹配, 忽略scheme与host
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// JSON body, 已脱敏并规范化
+	Body string `json:"body,omitempty"`
+	// 非JSON body(例如分块数据)的md5
+	BodyMD5 string `json:"bodyMD5,omitempty"`
+}
+
+// RecordedResponse 已脱敏的响应
+type RecordedResponse struct {
+	StatusCode int               `json:"statusCode"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body,omitempty"`
+}
+
+// Recorder 录制/回放HTTP请求的http.RoundTripper, 通过 pan123.WithTransport 接入
+//
+// 录制时accessToken、clientID、clientSecret、提取码及预签名地址的query参数均会被脱敏;
+// 回放时请求按录制顺序匹配, 每条记录仅使用一次, 未匹配的请求及测试结束时未使用的记录均会使测试失败
+type Recorder struct {
+	t        testing.TB
+	path     string
+	mode     Mode
+	next     http.RoundTripper
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder 创建Recorder, 录制模式下测试结束时写入cassette文件
+//
+// @param t testing.TB
+//
+// @param path string cassette文件路径, 例如: testdata/sequential_pan123test.json
+//
+// @param mode Mode 工作模式, 例如: ModeFromEnv()
+//
+// @param next http.RoundTripper 录制时实际发起请求的Transport, 为nil时使用http.DefaultTransport
+//
+// @return *Recorder
+func NewRecorder(t testing.TB, path string, mode Mode, next http.RoundTripper) *Recorder {
+	t.Helper()
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{t: t, path: path, mode: mode, next: next}
+	if mode == ModeReplay {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("pan123test: read cassette: %s (set %s=1 to record)", err, RecordEnv)
+		}
+		if err := json.Unmarshal(buf, &r.cassette); err != nil {
+			t.Fatalf("pan123test: decode cassette %s: %s", path, err)
+		}
+	}
+	t.Cleanup(r.finish)
+	return r
+}
+
+// Mode 工作模式
+//
+// @return Mode
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// RoundTrip 实现http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := recordRequest(req, body)
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	_req := req.Clone(req.Context())
+	_req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	_req.ContentLength = int64(len(body))
+	resp, err := r.next.RoundTrip(_req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     map[string]string{},
+			Body:       redactResponseBody(respBody),
+		},
+	}
+	for _, k := range recordedHeaders {
+		if v := resp.Header.Get(k); v != "" {
+			interaction.Response.Header[k] = v
+		}
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.used || !interaction.Request.matches(recorded) {
+			continue
+		}
+		interaction.used = true
+
+		header := http.Header{}
+		for k, v := range interaction.Response.Header {
+			header.Set(k, v)
+		}
+		body := []byte(interaction.Response.Body)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	r.t.Errorf("pan123test: unmatched request %s %s body=%s", recorded.Method, recorded.URL, recorded.Body)
+	return nil, fmt.Errorf("pan123test: no recorded interaction for %s %s", recorded.Method, recorded.URL)
+}
+
+func (r *Recorder) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mode == ModeReplay {
+		for _, interaction := range r.cassette.Interactions {
+			if !interaction.used {
+				r.t.Errorf("pan123test: unused recorded interaction %s %s", interaction.Request.Method, interaction.Request.URL)
+			}
+		}
+		return
+	}
+
+	buf, err := json.MarshalIndent(&r.cassette, "", "  ")
+	if err != nil {
+		r.t.Errorf("pan123test: encode cassette: %s", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		r.t.Errorf("pan123test: create cassette dir: %s", err)
+		return
+	}
+	if err := ioutil.WriteFile(r.path, append(buf, '\n'), 0644); err != nil {
+		r.t.Errorf("pan123test: write cassette: %s", err)
+	}
+}
+
+func (rr RecordedRequest) matches(other RecordedRequest) bool {
+	return rr.Method == other.Method && requestURI(rr.URL) == requestURI(other.URL) &&
+		rr.Body == other.Body && rr.BodyMD5 == other.BodyMD5
+}
+
+func requestURI(rawURL string) string {
+	u, err := netUrl.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.RequestURI()
+}
+
+func recordRequest(req *http.Request, body []byte) RecordedRequest {
+	recorded := RecordedRequest{Method: req.Method}
+	if req.Method == "PUT" {
+		// 预签名地址的query参数均视为签名信息
+		recorded.URL = redactURLQuery(req.URL, true)
+		if len(body) > 0 {
+			recorded.BodyMD5 = fmt.Sprintf("%x", md5.Sum(body))
+		}
+		return recorded
+	}
+	recorded.URL = redactURLQuery(req.URL, false)
+	if len(body) > 0 {
+		if canonical, ok := redactJSON(body); ok {
+			recorded.Body = canonical
+		} else {
+			recorded.BodyMD5 = fmt.Sprintf("%x", md5.Sum(body))
+		}
+	}
+	return recorded
+}
+
+func redactURLQuery(u *netUrl.URL, all bool) string {
+	_u := *u
+	_u.User = nil
+	if _u.RawQuery == "" {
+		return _u.String()
+	}
+	q := _u.Query()
+	pairs := make([]string, 0, len(q))
+	for k, vs := range q {
+		for _, v := range vs {
+			if all || sensitiveKeys[strings.ToLower(k)] {
+				v = redacted
+			} else {
+				v = netUrl.QueryEscape(v)
+			}
+			pairs = append(pairs, netUrl.QueryEscape(k)+"="+v)
+		}
+	}
+	sort.Strings(pairs)
+	_u.RawQuery = strings.Join(pairs, "&")
+	return _u.String()
+}
+
+func redactResponseBody(body []byte) string {
+	if canonical, ok := redactJSON(body); ok {
+		return canonical
+	}
+	return string(body)
+}
+
+// redactJSON 脱敏并规范化JSON(对象的键按字典序排列), 非JSON时返回false
+func redactJSON(body []byte) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	// 保留int64精度
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	buf, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return "", false
+	}
+	return string(buf), true
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			key := strings.ToLower(k)
+			switch {
+			case sensitiveKeys[key]:
+				out[k] = redacted
+			case presignedURLKeys[key]:
+				out[k] = item
+				if s, ok := item.(string); ok {
+					if u, err := netUrl.Parse(s); err == nil {
+						out[k] = redactURLQuery(u, true)
+					}
+				}
+			default:
+				out[k] = redactValue(item)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	}
+	return v
+}
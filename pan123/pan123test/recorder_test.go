@@ -0,0 +1,89 @@
+package pan123test_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123"
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123test"
+)
+
+// captureTB 记录Errorf而不使测试失败
+type captureTB struct {
+	testing.TB
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (tb *captureTB) Errorf(format string, args ...interface{}) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+
+	t.Run("record", func(t *testing.T) {
+		srv := pan123test.NewServer(pan123test.WithSliceSize(4))
+		defer srv.Close()
+		rec := pan123test.NewRecorder(t, cassette, pan123test.ModeRecord, nil)
+		p123 := pan123.NewPan123WithOptions(pan123.WithBaseURL(srv.URL), pan123.WithTransport(rec), pan123.WithoutRateLimit())
+		token, _, err := p123.RequestAccessToken(pan123test.DefaultClientID, pan123test.DefaultClientSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p123.SetAccessToken(token)
+		if _, err := p123.FileUpload(pan123test.RootID, "a.txt", writeTempFile(t, []byte("0123456789")), 0); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	buf, err := ioutil.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{pan123test.DefaultClientID, pan123test.DefaultClientSecret, "fake-token-"} {
+		if strings.Contains(string(buf), secret) {
+			t.Fatalf("cassette leaks %q", secret)
+		}
+	}
+	if strings.Count(string(buf), "signature=***") < 6 {
+		t.Fatalf("presigned urls not redacted:\n%s", buf)
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		rec := pan123test.NewRecorder(t, cassette, pan123test.ModeReplay, nil)
+		// 回放时不访问网络, baseURL可任意
+		p123 := pan123.NewPan123WithOptions(pan123.WithBaseURL("http://127.0.0.1:1"), pan123.WithTransport(rec), pan123.WithoutRateLimit())
+		token, _, err := p123.RequestAccessToken("other-id", "other-secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p123.SetAccessToken(token)
+		resp, err := p123.FileUpload(pan123test.RootID, "a.txt", writeTempFile(t, []byte("0123456789")), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.FileID == 0 {
+			t.Fatalf("resp = %+v", resp)
+		}
+	})
+
+	t.Run("unmatched", func(t *testing.T) {
+		tb := &captureTB{TB: t}
+		rec := pan123test.NewRecorder(tb, cassette, pan123test.ModeReplay, nil)
+		p123 := pan123.NewPan123WithOptions(pan123.WithTransport(rec), pan123.WithoutRateLimit())
+		if _, err := p123.GetUserInfo(); err == nil {
+			t.Fatal("expected error for unmatched request")
+		}
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "unmatched request GET") {
+			t.Fatalf("errors = %v", tb.errors)
+		}
+	})
+}
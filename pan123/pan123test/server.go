@@ -0,0 +1,391 @@
+// Package pan123test 提供进程内的123云盘OpenAPI模拟服务, 用于无网络、可重复的测试
+//
+// Server 实现了文件树(创建目录、文件列表v1/v2、移动、回收站、彻底删除、重命名、文件详情)、
+// 完整的上传流程(预签名分块地址、分块校验、异步合并)、分享链接、直链及离线下载等接口.
+//
+//	srv := pan123test.NewServer(pan123test.WithSliceSize(4))
+//	defer srv.Close()
+//
+//	p123 := pan123.NewPan123WithOptions(pan123.WithBaseURL(srv.URL))
+//	p123.SetAccessToken(pan123test.DefaultAccessToken)
+package pan123test
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultClientID 默认可用于获取accessToken的clientID
+	DefaultClientID = "fake-client-id"
+	// DefaultClientSecret 默认可用于获取accessToken的clientSecret
+	DefaultClientSecret = "fake-client-secret"
+	// DefaultAccessToken 预先签发的accessToken
+	DefaultAccessToken = "fake-access-token"
+	// DefaultSliceSize 默认分块大小
+	DefaultSliceSize int64 = 16 * 1024 * 1024
+	// DefaultTokenTTL 签发的accessToken有效期
+	DefaultTokenTTL = 30 * 24 * time.Hour
+
+	// RootID 根目录ID
+	RootID int64 = 0
+)
+
+// 接口返回的错误码
+const (
+	// CodeBadRequest 参数错误
+	CodeBadRequest = 1
+	// CodeUnauthorized accessToken无效或已过期
+	CodeUnauthorized = 401
+	// CodeRateLimited 请求过于频繁
+	CodeRateLimited = 429
+	// CodeNotFound 文件不存在
+	CodeNotFound = 5066
+)
+
+// Option NewServer的配置项
+type Option func(s *Server)
+
+// WithCredentials 设置可用于获取accessToken的clientID、clientSecret, 默认为DefaultClientID、DefaultClientSecret
+//
+// @param clientID string
+//
+// @param clientSecret string
+func WithCredentials(clientID, clientSecret string) Option {
+	return func(s *Server) {
+		s.clientID = clientID
+		s.clientSecret = clientSecret
+	}
+}
+
+// WithAccessToken 预先签发accessToken, 默认签发DefaultAccessToken
+//
+// @param accessToken string
+func WithAccessToken(accessToken string) Option {
+	return func(s *Server) {
+		s.tokens[accessToken] = time.Now().Add(s.tokenTTL)
+	}
+}
+
+// WithoutTokenCheck 接受任意非空accessToken
+func WithoutTokenCheck() Option {
+	return func(s *Server) {
+		s.skipTokenCheck = true
+	}
+}
+
+// WithTokenTTL 设置通过 /api/v1/access_token 签发的accessToken有效期, 默认为DefaultTokenTTL
+//
+// @param ttl time.Duration
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.tokenTTL = ttl
+	}
+}
+
+// WithSliceSize 设置创建上传任务时返回的分块大小, 默认为DefaultSliceSize
+//
+// @param sliceSize int64
+func WithSliceSize(sliceSize int64) Option {
+	return func(s *Server) {
+		s.sliceSize = sliceSize
+	}
+}
+
+// WithAsyncUpload 上传完成时返回async, 需轮询 /upload/v1/file/upload_async_result polls 次后才完成合并
+//
+// @param polls int 轮询次数, 0为同步完成(默认)
+func WithAsyncUpload(polls int) Option {
+	return func(s *Server) {
+		s.asyncPolls = polls
+	}
+}
+
+// Server 123云盘OpenAPI模拟服务, 可被多个goroutine并发访问
+type Server struct {
+	*httptest.Server
+
+	clientID       string
+	clientSecret   string
+	tokenTTL       time.Duration
+	skipTokenCheck bool
+	sliceSize      int64
+	asyncPolls     int
+
+	mu           sync.Mutex
+	tokens       map[string]time.Time
+	nextID       int64
+	nextTraceID  int64
+	files        map[int64]*node
+	uploads      map[string]*upload
+	shares       map[string]*Share
+	offlineTasks map[int64]*offlineTask
+	faults       map[string][]fault
+	requests     map[string]int
+}
+
+type fault struct {
+	httpStatus int
+	code       int
+	message    string
+}
+
+type apiError struct {
+	code    int
+	message string
+}
+
+func errBadRequest(format string, v ...interface{}) *apiError {
+	return &apiError{code: CodeBadRequest, message: fmt.Sprintf(format, v...)}
+}
+
+func errNotFound(fileID int64) *apiError {
+	return &apiError{code: CodeNotFound, message: fmt.Sprintf("文件不存在: %d", fileID)}
+}
+
+// NewServer 创建并启动模拟服务, 使用完毕后需调用Close
+//
+// @param opts ...Option 配置项
+//
+// @return *Server
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		clientID:     DefaultClientID,
+		clientSecret: DefaultClientSecret,
+		tokenTTL:     DefaultTokenTTL,
+		sliceSize:    DefaultSliceSize,
+		tokens:       map[string]time.Time{},
+		nextID:       RootID,
+		files:        map[int64]*node{},
+		uploads:      map[string]*upload{},
+		shares:       map[string]*Share{},
+		offlineTasks: map[int64]*offlineTask{},
+		faults:       map[string][]fault{},
+		requests:     map[string]int{},
+	}
+	s.tokens[DefaultAccessToken] = time.Now().Add(s.tokenTTL)
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.files[RootID] = &node{id: RootID, parentID: RootID, dir: true, createAt: time.Now()}
+
+	mux := http.NewServeMux()
+	s.route(mux)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *Server) route(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/access_token", s.api("POST", false, s.handleAccessToken))
+	mux.HandleFunc("/api/v1/user/info", s.api("GET", true, s.handleUserInfo))
+
+	mux.HandleFunc("/upload/v1/file/mkdir", s.api("POST", true, s.handleMkDir))
+	mux.HandleFunc("/api/v1/file/list", s.api("GET", true, s.handleFileList))
+	mux.HandleFunc("/api/v2/file/list", s.api("GET", true, s.handleFileListV2))
+	mux.HandleFunc("/api/v1/file/move", s.api("POST", true, s.handleMove))
+	mux.HandleFunc("/api/v1/file/trash", s.api("POST", true, s.handleTrash))
+	mux.HandleFunc("/api/v1/file/recover", s.api("POST", true, s.handleRecover))
+	mux.HandleFunc("/api/v1/file/delete", s.api("POST", true, s.handleDelete))
+	mux.HandleFunc("/api/v1/file/rename", s.api("POST", true, s.handleRename))
+	mux.HandleFunc("/api/v1/file/detail", s.api("GET", true, s.handleDetail))
+
+	mux.HandleFunc("/upload/v1/file/create", s.api("POST", true, s.handleUploadCreate))
+	mux.HandleFunc("/upload/v1/file/get_upload_url", s.api("POST", true, s.handleGetUploadURL))
+	mux.HandleFunc("/upload/v1/file/list_upload_parts", s.api("POST", true, s.handleListUploadParts))
+	mux.HandleFunc("/upload/v1/file/upload_complete", s.api("POST", true, s.handleUploadComplete))
+	mux.HandleFunc("/upload/v1/file/upload_async_result", s.api("POST", true, s.handleUploadAsyncResult))
+	mux.HandleFunc(chunkPathPrefix, s.handleChunkPut)
+
+	mux.HandleFunc("/api/v1/share/create", s.api("POST", true, s.handleShareCreate))
+
+	mux.HandleFunc("/api/v1/direct-link/enable", s.api("POST", true, s.handleDirectLinkEnable))
+	mux.HandleFunc("/api/v1/direct-link/disable", s.api("POST", true, s.handleDirectLinkDisable))
+	mux.HandleFunc("/api/v1/direct-link/url", s.api("GET", true, s.handleDirectLinkURL))
+	mux.HandleFunc("/api/v1/direct-link/queryTranscode", s.api("POST", true, s.handleQueryTranscode))
+	mux.HandleFunc("/api/v1/direct-link/doTranscode", s.api("POST", true, s.handleDoTranscode))
+	mux.HandleFunc("/api/v1/direct-link/get/m3u8", s.api("GET", true, s.handleM3u8))
+
+	mux.HandleFunc("/api/v1/offline/download", s.api("POST", true, s.handleOfflineDownload))
+	mux.HandleFunc("/api/v1/offline/download/process", s.api("GET", true, s.handleOfflineProcess))
+}
+
+// api 包装JSON接口: 校验请求方法与accessToken, 注入故障, 加锁后调用h并写入统一格式的响应
+func (s *Server) api(method string, withAuth bool, h func(r *http.Request) (interface{}, *apiError)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests[r.URL.Path]++
+		s.nextTraceID++
+		traceID := fmt.Sprintf("fake-trace-%d", s.nextTraceID)
+
+		if f, ok := s.popFault(r.URL.Path); ok {
+			if f.httpStatus != 0 {
+				w.WriteHeader(f.httpStatus)
+				return
+			}
+			writeResponse(w, traceID, nil, &apiError{code: f.code, message: f.message})
+			return
+		}
+		if withAuth && !s.authorized(r) {
+			writeResponse(w, traceID, nil, &apiError{code: CodeUnauthorized, message: "tokens number has exceeded the limit"})
+			return
+		}
+
+		data, apiErr := h(r)
+		writeResponse(w, traceID, data, apiErr)
+	}
+}
+
+func writeResponse(w http.ResponseWriter, traceID string, data interface{}, apiErr *apiError) {
+	resp := map[string]interface{}{
+		"code":      0,
+		"message":   "ok",
+		"data":      data,
+		"x-traceID": traceID,
+	}
+	if apiErr != nil {
+		resp["code"] = apiErr.code
+		resp["message"] = apiErr.message
+		resp["data"] = nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if s.skipTokenCheck {
+		return token != ""
+	}
+	expiredAt, ok := s.tokens[token]
+	return ok && time.Now().Before(expiredAt)
+}
+
+func decodeBody(r *http.Request, v interface{}) *apiError {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errBadRequest("请求参数错误: %s", err)
+	}
+	return nil
+}
+
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func md5Hex(b []byte) string {
+	return fmt.Sprintf("%x", md5.Sum(b))
+}
+
+func (s *Server) popFault(path string) (fault, bool) {
+	faults := s.faults[path]
+	if len(faults) == 0 {
+		return fault{}, false
+	}
+	s.faults[path] = faults[1:]
+	return faults[0], true
+}
+
+// FailNext 接口的后续times次请求返回HTTP状态码statusCode
+//
+// @param path string 接口路径, 例如: /api/v1/file/move
+//
+// @param times int 次数
+//
+// @param statusCode int HTTP状态码, 例如: http.StatusServiceUnavailable
+func (s *Server) FailNext(path string, times int, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < times; i++ {
+		s.faults[path] = append(s.faults[path], fault{httpStatus: statusCode})
+	}
+}
+
+// FailNextWithCode 接口的后续times次请求返回错误码code
+//
+// @param path string 接口路径, 例如: /api/v1/file/move
+//
+// @param times int 次数
+//
+// @param code int 错误码, 例如: CodeRateLimited
+//
+// @param message string 错误信息
+func (s *Server) FailNextWithCode(path string, times int, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < times; i++ {
+		s.faults[path] = append(s.faults[path], fault{code: code, message: message})
+	}
+}
+
+// RequestCount 接口收到的请求数, 包含失败的请求
+//
+// @param path string 接口路径
+//
+// @return int
+func (s *Server) RequestCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// ExpireTokens 使所有已签发的accessToken失效
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]time.Time{}
+}
+
+func (s *Server) handleAccessToken(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		ClientID     string `json:"clientID"`
+		ClientSecret string `json:"clientSecret"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if req.ClientID != s.clientID || req.ClientSecret != s.clientSecret {
+		return nil, errBadRequest("clientID或clientSecret错误")
+	}
+
+	token := fmt.Sprintf("fake-token-%d", s.newID())
+	expiredAt := time.Now().Add(s.tokenTTL)
+	s.tokens[token] = expiredAt
+	return map[string]interface{}{
+		"accessToken": token,
+		"expiredAt":   expiredAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *Server) handleUserInfo(_ *http.Request) (interface{}, *apiError) {
+	var used int64
+	for _, n := range s.files {
+		used += int64(len(n.content))
+	}
+	return map[string]interface{}{
+		"uid":            1000,
+		"nickname":       "pan123test",
+		"headImage":      "",
+		"passport":       "",
+		"mail":           "pan123test@example.com",
+		"spaceUsed":      used,
+		"spacePermanent": int64(2) << 40,
+		"spaceTemp":      0,
+		"spaceTempExpr":  "",
+	}, nil
+}
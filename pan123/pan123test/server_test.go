@@ -0,0 +1,346 @@
+package pan123test_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123"
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123test"
+)
+
+func newClient(t *testing.T, opts ...pan123test.Option) (*pan123test.Server, *pan123.Pan123) {
+	srv := pan123test.NewServer(opts...)
+	t.Cleanup(srv.Close)
+	p123 := pan123.NewPan123WithOptions(pan123.WithBaseURL(srv.URL), pan123.WithoutRateLimit())
+	p123.SetAccessToken(pan123test.DefaultAccessToken)
+	return srv, p123
+}
+
+func writeTempFile(t *testing.T, content []byte) *os.File {
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = file.Close() })
+	return file
+}
+
+func TestAccessToken(t *testing.T) {
+	srv, p123 := newClient(t)
+	token, expiredAt, err := p123.RequestAccessToken(pan123test.DefaultClientID, pan123test.DefaultClientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == "" || expiredAt.IsZero() {
+		t.Fatalf("token = %q, expiredAt = %s", token, expiredAt)
+	}
+	if _, _, err := p123.RequestAccessToken(pan123test.DefaultClientID, "wrong"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	srv.ExpireTokens()
+	if _, err := p123.GetUserInfo(); !errors.Is(err, pan123.ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestFileTree(t *testing.T) {
+	srv, p123 := newClient(t)
+
+	dir, err := p123.MkDir("docs", pan123test.RootID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p123.MkDir("docs", pan123test.RootID); err == nil {
+		t.Fatal("expected duplicate mkdir error")
+	}
+	fileID := srv.AddFile(pan123test.RootID, "a.txt", []byte("hello"))
+
+	if err := p123.MoveFile([]int64{fileID}, dir.DirID); err != nil {
+		t.Fatal(err)
+	}
+	list, err := p123.GetFileList(dir.DirID, 1, 100, "file_id", "asc", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.FileList) != 1 || list.FileList[0].FileID != fileID || list.FileList[0].ParentName != "docs" {
+		t.Fatalf("list = %+v", list)
+	}
+
+	if err := p123.RenameFile([]string{"0|x", "1"}); err == nil {
+		t.Fatal("expected rename error")
+	}
+	if err := p123.RenameFile([]string{fmtID(fileID) + "|b.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	detail, err := p123.GetFileDetail(fileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Filename != "b.txt" || detail.Size != 5 || detail.ParentFileID != dir.DirID || detail.Trashed != 0 {
+		t.Fatalf("detail = %+v", detail)
+	}
+
+	if err := p123.DeleteFile([]int64{fileID}); err == nil {
+		t.Fatal("expected error deleting file not in trash")
+	}
+	if err := p123.TrashFile([]int64{fileID}); err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := p123.GetFileList(0, 1, 100, "file_id", "asc", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed.FileList) != 1 {
+		t.Fatalf("trashed = %+v", trashed)
+	}
+	if err := p123.RecoverFile([]int64{fileID}); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := srv.File(fileID); f.Trashed {
+		t.Fatal("file still trashed")
+	}
+	if err := p123.TrashFile([]int64{fileID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p123.DeleteFile([]int64{fileID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p123.GetFileDetail(fileID); !errors.Is(err, pan123.ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileListV2Pagination(t *testing.T) {
+	srv, p123 := newClient(t)
+	for i := 0; i < 5; i++ {
+		srv.AddFile(pan123test.RootID, "file-"+fmtID(int64(i))+".mp4", []byte{byte(i)})
+	}
+
+	var ids []int64
+	lastFileID := int64(-1)
+	for page := 0; ; page++ {
+		list, err := p123.GetFileListV2(pan123test.RootID, 2, "", -1, lastFileID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range list.FileList {
+			ids = append(ids, f.FileID)
+			if f.Category != 2 {
+				t.Fatalf("category = %d", f.Category)
+			}
+		}
+		if list.LastFileId == -1 {
+			break
+		}
+		lastFileID = list.LastFileId
+		if page > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+	}
+	if len(ids) != 5 {
+		t.Fatalf("ids = %v", ids)
+	}
+
+	found, err := p123.GetFileListV2(pan123test.RootID, 100, "file-3.mp4", 1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found.FileList) != 1 || found.FileList[0].Filename != "file-3.mp4" {
+		t.Fatalf("found = %+v", found)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	srv, p123 := newClient(t, pan123test.WithSliceSize(4), pan123test.WithAsyncUpload(2))
+	content := []byte("0123456789")
+
+	var statuses []pan123.FileUploadCallbackStatus
+	resp, err := p123.FileUploadWithCallback(pan123test.RootID, "upload.txt", writeTempFile(t, content), 1, func(info pan123.FileUploadCallbackInfo) {
+		switch info.Status {
+		case pan123.FILE_UPLOAD_CALLBACK_STATUS_HASH_PROGRESS, pan123.FILE_UPLOAD_CALLBACK_STATUS_UPLOAD_PROGRESS:
+			// 进度Callback的次数取决于耗时
+		default:
+			statuses = append(statuses, info.Status)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Async || resp.PreuploadID == "" {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if parts := srv.UploadedParts(resp.PreuploadID); len(parts) != 3 {
+		t.Fatalf("parts = %v", parts)
+	}
+	// CREATE_FILE, 3 x FIRST_UPLOAD_CHUNK, VERIFY_CHUNK, REPORT_COMPLETE, DONE
+	if len(statuses) != 7 || statuses[4] != pan123.FILE_UPLOAD_CALLBACK_STATUS_VERIFY_CHUNK || statuses[6] != pan123.FILE_UPLOAD_CALLBACK_STATUS_DONE {
+		t.Fatalf("statuses = %v", statuses)
+	}
+
+	var result *pan123.UploadAsyncResultRespData
+	for i := 0; i < 2; i++ {
+		if result, err = p123.GetUploadAsyncResult(resp.PreuploadID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !result.Completed || result.FileID == 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if got, _ := srv.FileContent(result.FileID); !bytes.Equal(got, content) {
+		t.Fatalf("content = %q", got)
+	}
+
+	// 相同内容秒传
+	reused, err := p123.FileUpload(pan123test.RootID, "copy.txt", writeTempFile(t, content), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reused.Reuse || reused.FileID == 0 || srv.Uploads() != 1 {
+		t.Fatalf("reused = %+v, uploads = %d", reused, srv.Uploads())
+	}
+}
+
+func TestUploadChunkFailure(t *testing.T) {
+	srv, p123 := newClient(t, pan123test.WithSliceSize(4))
+	srv.FailNext("/upload-chunk/", 1, http.StatusInternalServerError)
+
+	_, err := p123.FileUpload(pan123test.RootID, "upload.txt", writeTempFile(t, []byte("0123456789")), 0)
+	if !errors.Is(err, pan123.ErrUploadFailed) {
+		t.Fatalf("err = %v, want ErrUploadFailed", err)
+	}
+	if n := srv.RequestCount("/upload-chunk/"); n != 1 {
+		t.Fatalf("chunk requests = %d, want 1", n)
+	}
+}
+
+func TestUploadChunkRetry(t *testing.T) {
+	srv, p123 := newClient(t, pan123test.WithSliceSize(4))
+	srv.FailNext("/upload-chunk/", 1, http.StatusInternalServerError)
+
+	content := []byte("0123456789")
+	resp, err := p123.FileUpload(pan123test.RootID, "upload.txt", writeTempFile(t, content), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := srv.FileContent(resp.FileID); string(got) != string(content) {
+		t.Fatalf("content = %q", got)
+	}
+	if n := srv.RequestCount("/upload-chunk/"); n != 4 {
+		t.Fatalf("chunk requests = %d, want 4", n)
+	}
+}
+
+func TestShareAndDirectLink(t *testing.T) {
+	srv, p123 := newClient(t)
+	dirID := srv.AddDir(pan123test.RootID, "public")
+	fileID := srv.AddFile(dirID, "movie.mp4", []byte("movie"))
+
+	share, err := p123.CreateShare("share", fmtID(fileID), "pwd1", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := srv.Share(share.ShareKey); !ok || got.SharePwd != "pwd1" || got.FileIDs[0] != fileID {
+		t.Fatalf("share = %+v", got)
+	}
+
+	if _, err := p123.GetDirectLinkUrl(fileID); err == nil {
+		t.Fatal("expected error before enabling direct link")
+	}
+	enabled, err := p123.EnableDirectLink(dirID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enabled.Filename != "public" {
+		t.Fatalf("enabled = %+v", enabled)
+	}
+	link, err := p123.GetDirectLinkUrl(fileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Url != srv.URL+"/direct-link/public/movie.mp4" {
+		t.Fatalf("url = %q", link.Url)
+	}
+
+	if err := p123.DoDirectLinkTranscode([]int64{fileID}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"running", "success"} {
+		q, err := p123.QueryDirectLinkTranscode([]int64{fileID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (want == "running" && len(q.Running) != 1) || (want == "success" && len(q.Success) != 1) {
+			t.Fatalf("query = %+v, want %s", q, want)
+		}
+	}
+	m3u8, err := p123.GetDirectLinkM3u8(fileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m3u8.List) == 0 {
+		t.Fatal("empty m3u8 list")
+	}
+
+	if _, err := p123.DisableDirectLink(dirID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p123.GetDirectLinkUrl(fileID); err == nil {
+		t.Fatal("expected error after disabling direct link")
+	}
+}
+
+func TestOfflineDownload(t *testing.T) {
+	srv, p123 := newClient(t)
+	dirID := srv.AddDir(pan123test.RootID, "downloads")
+
+	created, err := p123.OfflineDownload("https://example.com/a/file.zip", "", "", dirID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []int{0, 2} {
+		process, err := p123.GetOfflineDownloadProcess(created.TaskID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if process.Status != want {
+			t.Fatalf("process = %+v, want status %d", process, want)
+		}
+	}
+	if _, ok := srv.Lookup(dirID, "file.zip"); !ok {
+		t.Fatal("downloaded file not found")
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	srv, p123 := newClient(t)
+	srv.FailNextWithCode("/api/v1/user/info", 1, pan123test.CodeRateLimited, "too many requests")
+	if _, err := p123.GetUserInfo(); !errors.Is(err, pan123.ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	srv.FailNext("/api/v1/user/info", 1, http.StatusBadGateway)
+	if _, err := p123.GetUserInfo(); !errors.Is(err, pan123.ErrHTTPStatus) {
+		t.Fatalf("err = %v, want ErrHTTPStatus", err)
+	}
+	info, err := p123.GetUserInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Uid == 0 || srv.RequestCount("/api/v1/user/info") != 3 {
+		t.Fatalf("info = %+v", info)
+	}
+}
+
+func fmtID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
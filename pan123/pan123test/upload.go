@@ -0,0 +1,298 @@
+package pan123test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 预签名分块上传地址的路径前缀, 完整格式为: /upload-chunk/{preuploadID}/{sliceNo}?signature=xxx
+const chunkPathPrefix = "/upload-chunk/"
+
+type upload struct {
+	preuploadID string
+	parentID    int64
+	filename    string
+	etag        string
+	size        int64
+	sliceSize   int64
+
+	signatures map[int64]string
+	parts      map[int64][]byte
+
+	// upload_complete后剩余的轮询次数
+	pendingPolls int
+	completed    bool
+	content      []byte
+	fileID       int64
+}
+
+func (u *upload) sliceCount() int64 {
+	count := u.size / u.sliceSize
+	if u.size%u.sliceSize != 0 {
+		count++
+	}
+	return count
+}
+
+// Uploads 已创建的上传任务数量, 不包含秒传
+//
+// @return int
+func (s *Server) Uploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+// UploadedParts 上传任务已上传的分块序号, 从1开始
+//
+// @param preuploadID string 预上传ID
+//
+// @return []int64
+func (s *Server) UploadedParts(preuploadID string) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[preuploadID]
+	if !ok {
+		return nil
+	}
+	sliceNos := make([]int64, 0, len(u.parts))
+	for sliceNo := range u.parts {
+		sliceNos = append(sliceNos, sliceNo)
+	}
+	sort.Slice(sliceNos, func(i, j int) bool { return sliceNos[i] < sliceNos[j] })
+	return sliceNos
+}
+
+func (s *Server) upload(preuploadID string) (*upload, *apiError) {
+	u, ok := s.uploads[preuploadID]
+	if !ok {
+		return nil, errBadRequest("preuploadID不存在: %s", preuploadID)
+	}
+	return u, nil
+}
+
+func (s *Server) handleUploadCreate(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		ParentFileID int64  `json:"parentFileID"`
+		Filename     string `json:"filename"`
+		Etag         string `json:"etag"`
+		Size         int64  `json:"size"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	if req.Filename == "" || utf8.RuneCountInString(req.Filename) >= 128 || strings.ContainsAny(req.Filename, "\"\\/:*?|><") {
+		return nil, errBadRequest("filename不合法: %s", req.Filename)
+	}
+	if req.Size <= 0 || len(req.Etag) != 32 {
+		return nil, errBadRequest("size或etag参数错误")
+	}
+	if _, apiErr := s.dir(req.ParentFileID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	// 已存在相同内容的文件时秒传
+	for _, n := range s.files {
+		if !n.dir && !n.trashed && n.etag == req.Etag && int64(len(n.content)) == req.Size {
+			reused := s.addNode(req.ParentFileID, req.Filename, false, n.content)
+			return map[string]interface{}{"fileID": reused.id, "reuse": true}, nil
+		}
+	}
+
+	u := &upload{
+		preuploadID: fmt.Sprintf("fake-preupload-%d", s.newID()),
+		parentID:    req.ParentFileID,
+		filename:    req.Filename,
+		etag:        req.Etag,
+		size:        req.Size,
+		sliceSize:   s.sliceSize,
+		signatures:  map[int64]string{},
+		parts:       map[int64][]byte{},
+	}
+	s.uploads[u.preuploadID] = u
+	return map[string]interface{}{
+		"fileID":      0,
+		"preuploadID": u.preuploadID,
+		"reuse":       false,
+		"sliceSize":   u.sliceSize,
+	}, nil
+}
+
+func (s *Server) handleGetUploadURL(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		PreuploadID string `json:"preuploadID"`
+		SliceNo     int64  `json:"sliceNo"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	u, apiErr := s.upload(req.PreuploadID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if u.completed || u.pendingPolls > 0 {
+		return nil, errBadRequest("上传任务已完成: %s", req.PreuploadID)
+	}
+	if req.SliceNo < 1 || req.SliceNo > u.sliceCount() {
+		return nil, errBadRequest("sliceNo超出范围: %d", req.SliceNo)
+	}
+
+	// 每次获取的签名均不同, 旧签名随之失效
+	signature := md5Hex([]byte(fmt.Sprintf("%s/%d/%d", u.preuploadID, req.SliceNo, s.newID())))
+	u.signatures[req.SliceNo] = signature
+	return map[string]interface{}{
+		"presignedURL": fmt.Sprintf("%s%s%s/%d?signature=%s", s.URL, chunkPathPrefix, u.preuploadID, req.SliceNo, signature),
+	}, nil
+}
+
+// handleChunkPut 模拟对象存储的预签名分块上传
+func (s *Server) handleChunkPut(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, chunkPathPrefix), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	sliceNo, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[chunkPathPrefix]++
+	if f, ok := s.popFault(chunkPathPrefix); ok && f.httpStatus != 0 {
+		w.WriteHeader(f.httpStatus)
+		return
+	}
+	u, ok := s.uploads[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if signature := u.signatures[sliceNo]; signature == "" || r.URL.Query().Get("signature") != signature {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if int64(len(buf)) > u.sliceSize || len(buf) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	u.parts[sliceNo] = buf
+	w.Header().Set("ETag", strconv.Quote(md5Hex(buf)))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleListUploadParts(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		PreuploadID string `json:"preuploadID"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	u, apiErr := s.upload(req.PreuploadID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	sliceNos := make([]int64, 0, len(u.parts))
+	for sliceNo := range u.parts {
+		sliceNos = append(sliceNos, sliceNo)
+	}
+	sort.Slice(sliceNos, func(i, j int) bool { return sliceNos[i] < sliceNos[j] })
+	parts := make([]map[string]interface{}, 0, len(sliceNos))
+	for _, sliceNo := range sliceNos {
+		parts = append(parts, map[string]interface{}{
+			"partNumber": strconv.FormatInt(sliceNo, 10),
+			"size":       len(u.parts[sliceNo]),
+			"etag":       md5Hex(u.parts[sliceNo]),
+		})
+	}
+	return map[string]interface{}{"parts": parts}, nil
+}
+
+func (s *Server) handleUploadComplete(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		PreuploadID string `json:"preuploadID"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	u, apiErr := s.upload(req.PreuploadID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if u.completed {
+		return map[string]interface{}{"fileID": u.fileID, "async": false, "completed": true}, nil
+	}
+	if u.pendingPolls > 0 {
+		return map[string]interface{}{"fileID": 0, "async": true, "completed": false}, nil
+	}
+
+	var content bytes.Buffer
+	for sliceNo := int64(1); sliceNo <= u.sliceCount(); sliceNo++ {
+		part, ok := u.parts[sliceNo]
+		if !ok {
+			return nil, errBadRequest("分块 %d 未上传", sliceNo)
+		}
+		content.Write(part)
+	}
+	if int64(content.Len()) != u.size || md5Hex(content.Bytes()) != u.etag {
+		return nil, errBadRequest("文件校验失败")
+	}
+	u.content = content.Bytes()
+
+	if s.asyncPolls > 0 {
+		u.pendingPolls = s.asyncPolls
+		return map[string]interface{}{"fileID": 0, "async": true, "completed": false}, nil
+	}
+	s.finishUpload(u)
+	return map[string]interface{}{"fileID": u.fileID, "async": false, "completed": true}, nil
+}
+
+func (s *Server) handleUploadAsyncResult(r *http.Request) (interface{}, *apiError) {
+	var req struct {
+		PreuploadID string `json:"preuploadID"`
+	}
+	if apiErr := decodeBody(r, &req); apiErr != nil {
+		return nil, apiErr
+	}
+	u, apiErr := s.upload(req.PreuploadID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !u.completed && u.pendingPolls > 0 {
+		u.pendingPolls--
+		if u.pendingPolls == 0 {
+			s.finishUpload(u)
+		}
+	}
+	if !u.completed {
+		return map[string]interface{}{"completed": false, "fileID": 0}, nil
+	}
+	return map[string]interface{}{"completed": true, "fileID": u.fileID}, nil
+}
+
+func (s *Server) finishUpload(u *upload) {
+	n := s.addNode(u.parentID, u.filename, false, u.content)
+	u.completed = true
+	u.fileID = n.id
+	u.parts = map[int64][]byte{}
+	u.content = nil
+}
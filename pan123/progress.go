@@ -0,0 +1,208 @@
+package pan123
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// 进度类Callback(HASH_PROGRESS、UPLOAD_PROGRESS)的最小间隔, 完成时不受限制
+	uploadProgressInterval = 200 * time.Millisecond
+	// 上传速度指数平滑系数, 越大越接近瞬时速度
+	uploadSpeedAlpha = 0.3
+)
+
+// uploadProgress 汇总上传进度并调用Callback, 并发安全
+//
+// 所有Callback均在持有锁时调用, 因此不会并发执行, 且进度单调有序
+type uploadProgress struct {
+	mu sync.Mutex
+	cb FileUploadCallbackFunc
+
+	total  int64
+	hashed int64
+	sent   int64
+
+	bytesPerSecond float64
+	sampleTime     time.Time
+	sampleBytes    int64
+	lastEmit       time.Time
+	finished       bool
+}
+
+func newUploadProgress(cb FileUploadCallbackFunc) *uploadProgress {
+	if cb == nil {
+		cb = func(_ FileUploadCallbackInfo) {}
+	}
+	return &uploadProgress{cb: cb}
+}
+
+// setTotal 设置文件总字节数
+func (p *uploadProgress) setTotal(total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+}
+
+// emit 补充进度字段并调用Callback
+func (p *uploadProgress) emit(info FileUploadCallbackInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.emitLocked(info)
+}
+
+func (p *uploadProgress) emitLocked(info FileUploadCallbackInfo) {
+	if p.finished {
+		return
+	}
+	info.TotalBytes = p.total
+	info.HashedBytes = p.hashed
+	info.SentBytes = p.sent
+	info.BytesPerSecond = p.bytesPerSecond
+	if p.bytesPerSecond > 0 && p.total > p.sent {
+		info.ETA = time.Duration(float64(p.total-p.sent) / p.bytesPerSecond * float64(time.Second))
+	}
+	p.lastEmit = time.Now()
+	p.cb(info)
+}
+
+// addHashed 记录计算md5读取的字节数
+func (p *uploadProgress) addHashed(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hashed += n
+	if (p.total > 0 && p.hashed >= p.total) || time.Since(p.lastEmit) >= uploadProgressInterval {
+		p.emitLocked(FileUploadCallbackInfo{Status: FILE_UPLOAD_CALLBACK_STATUS_HASH_PROGRESS})
+	}
+}
+
+// startSending 开始发送分块, uploaded为续传时已上传的字节数, 不计入上传速度
+func (p *uploadProgress) startSending(uploaded int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = uploaded
+	p.sampleBytes = uploaded
+	p.sampleTime = time.Now()
+}
+
+// addSent 记录发送的分块字节数, n小于0时表示回退失败分块已发送的字节数
+func (p *uploadProgress) addSent(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent += n
+	if n < 0 {
+		// 字节已实际发送, 保持采样区间内的发送量不变
+		p.sampleBytes += n
+		return
+	}
+
+	now := time.Now()
+	if dt := now.Sub(p.sampleTime); dt >= uploadProgressInterval {
+		speed := float64(p.sent-p.sampleBytes) / dt.Seconds()
+		if p.bytesPerSecond == 0 {
+			p.bytesPerSecond = speed
+		} else {
+			p.bytesPerSecond = uploadSpeedAlpha*speed + (1-uploadSpeedAlpha)*p.bytesPerSecond
+		}
+		p.sampleTime = now
+		p.sampleBytes = p.sent
+	}
+	if p.sent >= p.total || now.Sub(p.lastEmit) >= uploadProgressInterval {
+		p.emitLocked(FileUploadCallbackInfo{Status: FILE_UPLOAD_CALLBACK_STATUS_UPLOAD_PROGRESS})
+	}
+}
+
+// done 发送FILE_UPLOAD_CALLBACK_STATUS_DONE, 之后不再调用Callback
+func (p *uploadProgress) done(result *FileUploadRespData, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info := FileUploadCallbackInfo{Status: FILE_UPLOAD_CALLBACK_STATUS_DONE, Err: err}
+	if err == nil {
+		info.Result = result
+	}
+	p.emitLocked(info)
+	p.finished = true
+}
+
+// progressReader 统计分块请求体已被读取(发送)的字节数
+//
+// 实现io.Closer, Transport关闭请求体后不会再读取r
+type progressReader struct {
+	r        io.Reader
+	progress *uploadProgress
+
+	mu         sync.Mutex
+	n          int64
+	rolledBack bool
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newProgressReader(r io.Reader, progress *uploadProgress) *progressReader {
+	return &progressReader{r: r, progress: progress, closed: make(chan struct{})}
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	if n > 0 {
+		pr.mu.Lock()
+		if !pr.rolledBack {
+			pr.n += int64(n)
+			pr.progress.addSent(int64(n))
+		}
+		pr.mu.Unlock()
+	}
+	return n, err
+}
+
+// Close 标记请求体已关闭
+func (pr *progressReader) Close() error {
+	pr.closeOnce.Do(func() { close(pr.closed) })
+	return nil
+}
+
+// rollback 分块上传失败时回退已统计的字节数, 之后的读取不再统计
+func (pr *progressReader) rollback() {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if pr.rolledBack {
+		return
+	}
+	pr.rolledBack = true
+	pr.progress.addSent(-pr.n)
+}
+
+// sliceBuffer worker复用的分块缓冲区
+//
+// http.RoundTripper返回后仍可能读取请求体, 直至关闭请求体; 使用过该缓冲区的请求体未全部关闭时
+// (例如中间件替换了请求体), 不再复用该缓冲区, 重新分配
+type sliceBuffer struct {
+	buf    []byte
+	bodies []*progressReader
+}
+
+// get 返回可写入的缓冲区
+func (b *sliceBuffer) get(size int64) []byte {
+	for _, body := range b.bodies {
+		select {
+		case <-body.closed:
+		default:
+			b.buf = nil
+		}
+	}
+	b.bodies = b.bodies[:0]
+	if int64(len(b.buf)) < size {
+		b.buf = make([]byte, size)
+	}
+	return b.buf
+}
+
+// body 创建读取buf[:n]的请求体
+func (b *sliceBuffer) body(n int, progress *uploadProgress) *progressReader {
+	body := newProgressReader(bytes.NewReader(b.buf[:n]), progress)
+	b.bodies = append(b.bodies, body)
+	return body
+}
@@ -0,0 +1,117 @@
+package pan123
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUploadProgress(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	// 第2块首次上传时只发送部分数据即失败
+	var failed int32
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadConcurrency(2),
+		WithChunkMiddleware(func(next ChunkHandler) ChunkHandler {
+			return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+				if req.SliceNo == 2 && atomic.CompareAndSwapInt32(&failed, 0, 1) {
+					_, _ = io.CopyN(ioutil.Discard, req.Body, 2)
+					return nil, errors.New("connection reset")
+				}
+				return next(ctx, req)
+			}
+		}))
+	p123.SetAccessToken("token")
+
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz!")
+	total := int64(len(content))
+	var infos []FileUploadCallbackInfo
+	resp, err := p123.FileUploadFromReaderAt(0, "progress.txt", bytes.NewReader(content), total, 1, func(info FileUploadCallbackInfo) {
+		infos = append(infos, info)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hashed, sent bool
+	for i, info := range infos {
+		if info.TotalBytes != total {
+			t.Errorf("infos[%d].TotalBytes = %d", i, info.TotalBytes)
+		}
+		if info.SentBytes < 0 || info.SentBytes > total {
+			t.Errorf("infos[%d].SentBytes = %d", i, info.SentBytes)
+		}
+		switch info.Status {
+		case FILE_UPLOAD_CALLBACK_STATUS_HASH_PROGRESS:
+			hashed = hashed || info.HashedBytes == total
+		case FILE_UPLOAD_CALLBACK_STATUS_UPLOAD_PROGRESS:
+			sent = sent || info.SentBytes == total
+		case FILE_UPLOAD_CALLBACK_STATUS_DONE:
+			if i != len(infos)-1 {
+				t.Errorf("DONE at %d of %d", i, len(infos))
+			}
+		}
+	}
+	if !hashed || !sent {
+		t.Errorf("missing progress, hashed = %v, sent = %v", hashed, sent)
+	}
+	last := infos[len(infos)-1]
+	if last.Status != FILE_UPLOAD_CALLBACK_STATUS_DONE || last.Result != resp || last.Err != nil {
+		t.Errorf("last = %+v", last)
+	}
+	// 失败的分块已回退, 不重复统计
+	if last.SentBytes != total || last.HashedBytes != total {
+		t.Errorf("last sent = %d, hashed = %d, want %d", last.SentBytes, last.HashedBytes, total)
+	}
+}
+
+func TestUploadProgressDoneOnError(t *testing.T) {
+	p123 := NewPan123WithOptions()
+	var infos []FileUploadCallbackInfo
+	_, err := p123.FileUploadFromReaderAt(0, "a?.txt", bytes.NewReader([]byte("x")), 1, 0, func(info FileUploadCallbackInfo) {
+		infos = append(infos, info)
+	})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("err = %v, want ErrValidation", err)
+	}
+	if len(infos) != 1 || infos[0].Status != FILE_UPLOAD_CALLBACK_STATUS_DONE || infos[0].Err != err || infos[0].Result != nil {
+		t.Errorf("infos = %+v", infos)
+	}
+}
+
+func TestUploadProgressSpeed(t *testing.T) {
+	var last FileUploadCallbackInfo
+	p := newUploadProgress(func(info FileUploadCallbackInfo) { last = info })
+	p.setTotal(1000)
+	p.startSending(100)
+	time.Sleep(uploadProgressInterval + 50*time.Millisecond)
+	p.addSent(200)
+	if last.Status != FILE_UPLOAD_CALLBACK_STATUS_UPLOAD_PROGRESS || last.SentBytes != 300 {
+		t.Fatalf("last = %+v", last)
+	}
+	// 续传时已上传的100字节不计入速度
+	if last.BytesPerSecond <= 0 || last.BytesPerSecond > 200/uploadProgressInterval.Seconds() {
+		t.Errorf("BytesPerSecond = %f", last.BytesPerSecond)
+	}
+	wantETA := time.Duration(700 / last.BytesPerSecond * float64(time.Second))
+	if last.ETA != wantETA {
+		t.Errorf("ETA = %s, want %s", last.ETA, wantETA)
+	}
+
+	p.addSent(-50)
+	p.done(nil, nil)
+	if last.Status != FILE_UPLOAD_CALLBACK_STATUS_DONE || last.SentBytes != 250 {
+		t.Errorf("last = %+v", last)
+	}
+	// DONE之后不再调用Callback
+	p.addSent(750)
+	if last.Status != FILE_UPLOAD_CALLBACK_STATUS_DONE {
+		t.Errorf("callback after DONE: %+v", last)
+	}
+}
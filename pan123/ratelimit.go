@@ -0,0 +1,147 @@
+package pan123
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit 单个接口的令牌桶限流配置
+type RateLimit struct {
+	// 每秒请求数, 小于等于0时不限流
+	QPS float64
+	// 令牌桶容量, 即允许的瞬时并发请求数, 小于1时视为1
+	Burst int
+}
+
+// DefaultRateLimits 推荐的接口限流配置, 即接口目录(Endpoints)中各接口的RateLimit, 参考官方文档的QPS限制, 未列出的接口不限流
+//
+// 默认不启用客户端限流(与早期版本一致), 可通过WithDefaultRateLimits启用; 官方限制如有调整, 可通过WithRateLimit覆盖
+//
+// @return map[string]RateLimit
+func DefaultRateLimits() map[string]RateLimit {
+	limits := map[string]RateLimit{}
+	for _, ep := range endpoints {
+		if ep.RateLimit.QPS > 0 {
+			limits[ep.Path] = ep.RateLimit
+		}
+	}
+	return limits
+}
+
+// WithDefaultRateLimits 按DefaultRateLimits启用客户端限流, 已通过WithRateLimit设置的接口保留原配置
+func WithDefaultRateLimits() Option {
+	return func(p123 *Pan123) {
+		if p123.rateLimits == nil {
+			p123.rateLimits = map[string]RateLimit{}
+		}
+		for path, limit := range DefaultRateLimits() {
+			if _, ok := p123.rateLimits[path]; !ok {
+				p123.rateLimits[path] = limit
+			}
+		}
+	}
+}
+
+// WithRateLimit 设置指定接口的限流配置, 其他接口不受影响
+//
+// 同一Pan123实例的所有goroutine共享限流额度, 超出额度的请求会阻塞等待(可通过ctx取消)
+//
+// @param path string 接口路径, 例如: /api/v2/file/list
+//
+// @param limit RateLimit QPS小于等于0时该接口不限流
+func WithRateLimit(path string, limit RateLimit) Option {
+	return func(p123 *Pan123) {
+		if p123.rateLimits == nil {
+			p123.rateLimits = map[string]RateLimit{}
+		}
+		p123.rateLimits[path] = limit
+	}
+}
+
+// WithoutRateLimit 关闭客户端限流, 包括之前通过WithDefaultRateLimits、WithRateLimit设置的配置
+func WithoutRateLimit() Option {
+	return func(p123 *Pan123) {
+		p123.rateLimits = map[string]RateLimit{}
+	}
+}
+
+type rateLimiter struct {
+	// 创建后只读, 无需加锁
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(limits map[string]RateLimit) *rateLimiter {
+	l := &rateLimiter{buckets: map[string]*tokenBucket{}}
+	for path, limit := range limits {
+		if limit.QPS <= 0 {
+			continue
+		}
+		l.buckets[path] = newTokenBucket(limit)
+	}
+	return l
+}
+
+// wait 阻塞至path对应的令牌桶有可用额度
+func (l *rateLimiter) wait(ctx context.Context, path string) error {
+	bucket, ok := l.buckets[path]
+	if !ok {
+		return nil
+	}
+	return bucket.wait(ctx)
+}
+
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   limit.QPS,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return newCtxError(err)
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	// 预占一个令牌, 不足时按排队顺序计算等待时间
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		// 归还预占的令牌
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package pan123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketWait(t *testing.T) {
+	bucket := newTokenBucket(RateLimit{QPS: 50, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := bucket.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 首个请求立即通过, 其余5个请求各间隔20ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("elapsed = %s, want >= 100ms", elapsed)
+	}
+}
+
+func TestTokenBucketWaitContext(t *testing.T) {
+	bucket := newTokenBucket(RateLimit{QPS: 1, Burst: 1})
+	if err := bucket.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bucket.wait(ctx); err == nil {
+		t.Fatal("expected ctx error")
+	}
+}
+
+func TestRateLimitSharedAcrossGoroutines(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{}}`))
+	}))
+	defer srv.Close()
+
+	p123 := NewPan123WithOptions(
+		WithBaseURL(srv.URL),
+		WithRateLimit("/api/v1/file/detail", RateLimit{QPS: 40, Burst: 2}),
+	)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p123.GetFileDetail(1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	// 2个请求立即通过, 其余8个请求共需等待200ms
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Fatalf("elapsed = %s, want >= 200ms", elapsed)
+	}
+}
+
+func TestRateLimitOptIn(t *testing.T) {
+	// 默认不限流
+	if n := len(NewPan123(0, false).rateLimiter.buckets); n != 0 {
+		t.Errorf("default buckets = %d, want 0", n)
+	}
+
+	p123 := NewPan123WithOptions(WithRateLimit("/api/v1/file/detail", RateLimit{QPS: 40, Burst: 2}))
+	if n := len(p123.rateLimiter.buckets); n != 1 {
+		t.Errorf("buckets = %d, want 1", n)
+	}
+
+	p123 = NewPan123WithOptions(WithRateLimit("/api/v1/file/detail", RateLimit{QPS: 40, Burst: 2}), WithDefaultRateLimits())
+	defaults := DefaultRateLimits()
+	if _, ok := defaults["/api/v1/file/detail"]; !ok {
+		defaults["/api/v1/file/detail"] = RateLimit{}
+	}
+	if n := len(p123.rateLimiter.buckets); n != len(defaults) {
+		t.Errorf("buckets = %d, want %d", n, len(defaults))
+	}
+	if bucket := p123.rateLimiter.buckets["/api/v1/file/detail"]; bucket == nil || bucket.rate != 40 {
+		t.Errorf("WithDefaultRateLimits overrode WithRateLimit: %+v", bucket)
+	}
+}
@@ -0,0 +1,168 @@
+package pan123
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// RetryPolicy JSON接口请求的重试策略
+//
+// 默认不重试; 非幂等接口(如MoveFile、CreateShare)仅在可确认请求未被服务端处理时重试,
+// 可通过RetryNonIdempotent或ContextWithRetryNonIdempotent放开限制
+type RetryPolicy struct {
+	// 最大尝试次数(包含首次请求), 小于等于1时不重试
+	MaxAttempts int
+	// 首次重试前的等待时间
+	InitialBackoff time.Duration
+	// 最大等待时间
+	MaxBackoff time.Duration
+	// 每次重试等待时间的倍数, 小于1时视为1
+	Multiplier float64
+	// 随机抖动比例, 0~1, 等待时间会在 [1-Jitter, 1+Jitter] 倍之间随机
+	Jitter float64
+	// 是否允许重试所有非幂等接口
+	RetryNonIdempotent bool
+	// 自定义判断错误是否可重试, 为nil时使用DefaultRetryable
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy 推荐的重试策略: 最多尝试3次, 指数退避 500ms ~ 10s, 20%抖动
+//
+// @return RetryPolicy
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// chunkRetryPolicy 上传分块重试前的等待时间, 重试次数由上传方法的retry参数控制
+var chunkRetryPolicy = RetryPolicy{
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// WithRetryPolicy 设置JSON接口请求的重试策略, 不影响上传分块时的重试(由retry参数控制, 按指数退避 500ms ~ 10s 等待)
+//
+// @param policy RetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(p123 *Pan123) {
+		p123.retryPolicy = policy
+	}
+}
+
+type retryNonIdempotentCtxKey struct{}
+
+// ContextWithRetryNonIdempotent 允许本次调用在非幂等接口上按RetryPolicy重试
+//
+// @param ctx context.Context
+//
+// @return context.Context
+func ContextWithRetryNonIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryNonIdempotentCtxKey{}, true)
+}
+
+// DefaultRetryable 默认的可重试错误判断: 网络错误、HTTP 5xx、HTTP 429及接口限流错误码
+//
+// @param err error
+//
+// @return bool
+func DefaultRetryable(err error) bool {
+	var sdkError *SDKError
+	if !errors.As(err, &sdkError) {
+		return false
+	}
+	return sdkError.Retryable()
+}
+
+// isIdempotent 接口是否可安全重试, 未知接口中仅GET接口视为幂等
+func isIdempotent(method, path string) bool {
+	if ep, ok := endpointsByPath[path]; ok {
+		return ep.Idempotent
+	}
+	return method == "GET"
+}
+
+// notProcessed 错误能否确认请求未被服务端处理(被限流或未建立连接)
+func notProcessed(err error) bool {
+	var sdkError *SDKError
+	if !errors.As(err, &sdkError) {
+		return false
+	}
+	if errors.Is(sdkError, ErrRateLimited) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.Is(sdkError, ErrTransport) && errors.As(sdkError.Cause, &opErr) && opErr.Op == "dial"
+}
+
+func (p123 *Pan123) shouldRetry(ctx context.Context, method, path string, err error, attempt int) bool {
+	policy := p123.retryPolicy
+	if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	if !retryable(err) {
+		return false
+	}
+	if isIdempotent(method, path) || policy.RetryNonIdempotent {
+		return true
+	}
+	if v, _ := ctx.Value(retryNonIdempotentCtxKey{}).(bool); v {
+		return true
+	}
+	return notProcessed(err)
+}
+
+// backoff 第attempt次请求失败后的等待时间, 服务端返回Retry-After时以其为准
+func (policy RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var sdkError *SDKError
+	if errors.As(err, &sdkError) && sdkError.retryAfter > 0 {
+		if policy.MaxBackoff > 0 && sdkError.retryAfter > policy.MaxBackoff {
+			return policy.MaxBackoff
+		}
+		return sdkError.retryAfter
+	}
+
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(policy.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if policy.Jitter > 0 {
+		d *= 1 + policy.Jitter*(2*rand.Float64()-1)
+	}
+	if policy.MaxBackoff > 0 && d > float64(policy.MaxBackoff) {
+		d = float64(policy.MaxBackoff)
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return newCtxError(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+package pan123
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRetryTestServer(failures int32, fail func(w http.ResponseWriter)) (*httptest.Server, *int32) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= failures {
+			fail(w)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{}}`))
+	}))
+	return srv, &hits
+}
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 10 * time.Millisecond
+	return policy
+}
+
+func TestRetryIdempotentOnServerError(t *testing.T) {
+	srv, hits := newRetryTestServer(2, func(w http.ResponseWriter) {
+		// Retry-After会被MaxBackoff截断
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer srv.Close()
+
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithRetryPolicy(testRetryPolicy()), WithoutRateLimit())
+	if _, err := p123.GetUserInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(hits); n != 3 {
+		t.Fatalf("hits = %d, want 3", n)
+	}
+}
+
+func TestRetryDisabledByDefault(t *testing.T) {
+	srv, hits := newRetryTestServer(1, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer srv.Close()
+
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL))
+	if _, err := p123.GetUserInfo(); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Fatalf("hits = %d, want 1", n)
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	serverError := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	rateLimited := func(w http.ResponseWriter) {
+		_, _ = w.Write([]byte(`{"code":429,"message":"too many requests"}`))
+	}
+
+	tests := []struct {
+		name     string
+		fail     func(w http.ResponseWriter)
+		ctx      context.Context
+		wantErr  bool
+		wantHits int32
+	}{
+		{"server error is not retried", serverError, context.Background(), true, 1},
+		{"rate limit is retried", rateLimited, context.Background(), false, 2},
+		{"explicitly enabled", serverError, ContextWithRetryNonIdempotent(context.Background()), false, 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, hits := newRetryTestServer(1, tc.fail)
+			defer srv.Close()
+
+			p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithRetryPolicy(testRetryPolicy()), WithoutRateLimit())
+			err := p123.MoveFileContext(tc.ctx, []int64{1}, 0)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if n := atomic.LoadInt32(hits); n != tc.wantHits {
+				t.Fatalf("hits = %d, want %d", n, tc.wantHits)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Fatalf("parseRetryAfter(3) = %s", d)
+	}
+	if d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d <= 0 || d > time.Minute {
+		t.Fatalf("parseRetryAfter(date) = %s", d)
+	}
+	if d := parseRetryAfter("invalid"); d != 0 {
+		t.Fatalf("parseRetryAfter(invalid) = %s", d)
+	}
+}
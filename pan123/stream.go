@@ -0,0 +1,164 @@
+package pan123
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// StreamSpool UploadStream的暂存配置
+//
+// 上传前需要内容的md5及大小, UploadStream先将内容读取并暂存, 同时计算md5, 再按普通流程上传
+type StreamSpool struct {
+	// 内容不超过该大小时暂存于内存, 超过后转存至临时文件, 0为始终使用临时文件
+	MemoryThreshold int64
+	// 临时文件所在目录, 为空时使用os.TempDir()
+	TempDir string
+	// 内容的最大大小, 超过时返回ErrValidation, 0为不限制
+	MaxSize int64
+}
+
+// DefaultStreamSpool 默认的暂存配置: 16MiB以内暂存于内存, 超过后使用系统临时目录, 不限制大小
+//
+// @return StreamSpool
+func DefaultStreamSpool() StreamSpool {
+	return StreamSpool{
+		MemoryThreshold: 16 * 1024 * 1024,
+	}
+}
+
+// WithStreamSpool 设置UploadStream的暂存配置, 覆盖DefaultStreamSpool
+//
+// @param spool StreamSpool
+func WithStreamSpool(spool StreamSpool) Option {
+	return func(p123 *Pan123) {
+		p123.streamSpool = spool
+	}
+}
+
+// UploadStream 上传长度未知的内容, 例如: 管道、tar流、数据库导出
+//
+// 内容先按StreamSpool暂存(同时计算md5), 再按普通流程上传; 无论成功与否, 返回前均会删除临时文件
+//
+// @param parentFileID int64 父目录id, 上传到根目录时填写0
+//
+// @param filename string 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><。（注：不能重名）
+//
+// @param r io.Reader 要上传的内容, 读取至io.EOF
+//
+// @param retry int 上传单一文件块时的重试次数, 0为不重试
+//
+// @param cb FileUploadCallbackFunc Callback, 可为nil
+//
+// @return FileUploadRespData
+//
+// @return SDKError
+func (p123 *Pan123) UploadStream(parentFileID int64, filename string, r io.Reader, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error) {
+	return p123.UploadStreamContext(context.Background(), parentFileID, filename, r, retry, cb)
+}
+
+// UploadStreamContext 同UploadStream, 支持通过ctx控制超时与取消
+func (p123 *Pan123) UploadStreamContext(ctx context.Context, parentFileID int64, filename string, r io.Reader, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error) {
+	progress := newUploadProgress(cb)
+	// 读取内容前校验, 避免暂存后才发现文件名不合法
+	if err := validateFilename("filename", filename); err != nil {
+		progress.done(nil, err)
+		return nil, err
+	}
+
+	s, err := spoolStream(ctx, r, p123.streamSpool, progress)
+	if s != nil {
+		defer s.close()
+	}
+	if err != nil {
+		progress.done(nil, err)
+		return nil, err
+	}
+
+	return p123.uploadContent(ctx, parentFileID, filename, s.readerAt(), s.size, time.Time{}, s.md5Sum, retry, progress)
+}
+
+// spooledStream 已暂存的内容
+type spooledStream struct {
+	buf    bytes.Buffer
+	file   *os.File
+	size   int64
+	md5Sum string
+}
+
+// spoolStream 读取r至io.EOF并暂存, 返回非nil的spooledStream时调用方需close
+func spoolStream(ctx context.Context, r io.Reader, cfg StreamSpool, progress *uploadProgress) (*spooledStream, error) {
+	s := &spooledStream{}
+	hash := md5.New()
+	readBuf := make([]byte, 256*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return s, newCtxError(err)
+		}
+		n, readErr := r.Read(readBuf)
+		if n > 0 {
+			s.size += int64(n)
+			if cfg.MaxSize > 0 && s.size > cfg.MaxSize {
+				return s, newValidationError("r", "size exceeds %d bytes", cfg.MaxSize)
+			}
+			_, _ = hash.Write(readBuf[:n])
+			progress.addHashed(int64(n))
+			if err := s.write(readBuf[:n], cfg); err != nil {
+				return s, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return s, newKindError(ErrFileIO, fmt.Sprintf("r.Read error: %s", readErr), readErr)
+		}
+	}
+	s.md5Sum = fmt.Sprintf("%x", hash.Sum(nil))
+	return s, nil
+}
+
+// write 写入暂存区, 超过MemoryThreshold时将内存中的内容转存至临时文件
+func (s *spooledStream) write(p []byte, cfg StreamSpool) error {
+	if s.file == nil && s.size > cfg.MemoryThreshold {
+		file, err := ioutil.TempFile(cfg.TempDir, "pan123-stream-*")
+		if err != nil {
+			return newKindError(ErrFileIO, fmt.Sprintf("ioutil.TempFile error: %s", err), err)
+		}
+		s.file = file
+		if _, err := s.file.Write(s.buf.Bytes()); err != nil {
+			return newKindError(ErrFileIO, fmt.Sprintf("tempFile.Write error: %s", err), err)
+		}
+		s.buf = bytes.Buffer{}
+	}
+	if s.file != nil {
+		if _, err := s.file.Write(p); err != nil {
+			return newKindError(ErrFileIO, fmt.Sprintf("tempFile.Write error: %s", err), err)
+		}
+		return nil
+	}
+	s.buf.Write(p)
+	return nil
+}
+
+func (s *spooledStream) readerAt() io.ReaderAt {
+	if s.file != nil {
+		return s.file
+	}
+	return bytes.NewReader(s.buf.Bytes())
+}
+
+// close 删除临时文件并释放内存
+func (s *spooledStream) close() {
+	s.buf = bytes.Buffer{}
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+}
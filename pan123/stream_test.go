@@ -0,0 +1,95 @@
+package pan123
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123test"
+)
+
+func assertDirEmpty(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("temp file %s not removed", entry.Name())
+	}
+}
+
+func TestUploadStream(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	content := bytes.Repeat([]byte("stream-"), 100)
+	for _, threshold := range []int64{0, 64, int64(len(content))} {
+		tempDir := t.TempDir()
+		p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(),
+			WithStreamSpool(StreamSpool{MemoryThreshold: threshold, TempDir: tempDir}))
+		p123.SetAccessToken("token")
+
+		// 通过管道分多次写入, 模拟长度未知的输入
+		pr, pw := io.Pipe()
+		go func() {
+			for i := 0; i < len(content); i += 70 {
+				end := i + 70
+				if end > len(content) {
+					end = len(content)
+				}
+				_, _ = pw.Write(content[i:end])
+			}
+			_ = pw.Close()
+		}()
+		resp, err := p123.UploadStream(0, fmt.Sprintf("stream-%d.txt", threshold), pr, 0, nil)
+		if err != nil {
+			t.Fatalf("threshold %d: %s", threshold, err)
+		}
+		got, ok := srv.FileContent(resp.FileID)
+		if !ok || !bytes.Equal(got, content) {
+			t.Errorf("threshold %d: content mismatch", threshold)
+		}
+		assertDirEmpty(t, tempDir)
+	}
+}
+
+func TestUploadStreamFailure(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	tempDir := t.TempDir()
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(),
+		WithStreamSpool(StreamSpool{TempDir: tempDir, MaxSize: 16}))
+	p123.SetAccessToken("token")
+
+	// 接口失败时仍删除临时文件
+	srv.FailNextWithCode("/upload/v1/file/create", 1, pan123test.CodeNotFound, "not found")
+	if _, err := p123.UploadStream(0, "fail.txt", strings.NewReader("0123456789"), 0, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	assertDirEmpty(t, tempDir)
+
+	// 超过MaxSize
+	_, err := p123.UploadStream(0, "big.txt", strings.NewReader(strings.Repeat("x", 17)), 0, nil)
+	var sdkErr *SDKError
+	if !errors.As(err, &sdkErr) || !errors.Is(err, ErrValidation) || sdkErr.Field != "r" {
+		t.Errorf("err = %v, want validation error", err)
+	}
+	assertDirEmpty(t, tempDir)
+
+	// 读取失败
+	readErr := errors.New("broken pipe")
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("partial"))
+		_ = pw.CloseWithError(readErr)
+	}()
+	if _, err := p123.UploadStream(0, "broken.txt", pr, 0, nil); !errors.Is(err, ErrFileIO) || !errors.Is(err, readErr) {
+		t.Errorf("err = %v, want ErrFileIO", err)
+	}
+	assertDirEmpty(t, tempDir)
+}
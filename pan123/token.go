@@ -0,0 +1,229 @@
+package pan123
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTokenRefreshBefore 默认在accessToken过期前多久进行刷新
+	DefaultTokenRefreshBefore = 5 * time.Minute
+	// 提前刷新失败后, 缓存的Token未过期时在该时间内不再刷新, 避免频繁请求限流的access_token接口
+	tokenRefreshFailureBackoff = 30 * time.Second
+)
+
+// Token accessToken及其过期时间
+type Token struct {
+	// accessToken
+	AccessToken string `json:"accessToken"`
+	// accessToken过期时间
+	ExpiredAt time.Time `json:"expiredAt"`
+}
+
+// Valid 判断Token在refreshBefore时间后是否仍然有效
+//
+// @param refreshBefore time.Duration 提前刷新时间(时钟偏差余量)
+//
+// @return bool
+func (t *Token) Valid(refreshBefore time.Duration) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	if t.ExpiredAt.IsZero() {
+		// 未知过期时间, 视为一直有效, 直到接口返回401
+		return true
+	}
+	return time.Now().Add(refreshBefore).Before(t.ExpiredAt)
+}
+
+// TokenSource accessToken提供者
+//
+// 通过WithTokenSource设置后, 每次需要鉴权的请求都会调用Token获取accessToken,
+// 当接口返回401时会调用Invalidate并重新获取accessToken后重试一次
+type TokenSource interface {
+	// Token 返回当前有效的Token
+	Token(ctx context.Context) (*Token, error)
+	// Invalidate 通知accessToken已失效, 下次调用Token时需重新获取
+	Invalidate(accessToken string)
+}
+
+// TokenFetchFunc 获取新的Token
+type TokenFetchFunc func(ctx context.Context) (*Token, error)
+
+type tokenFetchCall struct {
+	done  chan struct{}
+	token *Token
+	err   error
+	// 发起刷新的调用者是否已取消
+	cancelled bool
+}
+
+type refreshTokenSource struct {
+	fetch         TokenFetchFunc
+	refreshBefore time.Duration
+
+	mu       sync.Mutex
+	token    *Token
+	inflight *tokenFetchCall
+	// 最近一次提前刷新失败的时间
+	failedAt time.Time
+}
+
+// NewRefreshTokenSource 创建自动刷新的TokenSource
+//
+// 缓存fetch返回的Token, 在过期前refreshBefore时间内自动重新获取; 并发的刷新请求会合并为一次fetch调用
+//
+// @param fetch TokenFetchFunc 获取新Token的方法
+//
+// @param refreshBefore time.Duration 在过期前多久进行刷新, 小于0时使用DefaultTokenRefreshBefore
+//
+// @return TokenSource
+func NewRefreshTokenSource(fetch TokenFetchFunc, refreshBefore time.Duration) TokenSource {
+	if refreshBefore < 0 {
+		refreshBefore = DefaultTokenRefreshBefore
+	}
+	return &refreshTokenSource{
+		fetch:         fetch,
+		refreshBefore: refreshBefore,
+	}
+}
+
+func (ts *refreshTokenSource) Token(ctx context.Context) (*Token, error) {
+	for {
+		ts.mu.Lock()
+		if ts.token.Valid(ts.refreshBefore) || (ts.token.Valid(0) && time.Since(ts.failedAt) < tokenRefreshFailureBackoff) {
+			token := ts.token
+			ts.mu.Unlock()
+			return token, nil
+		}
+		call := ts.inflight
+		if call == nil {
+			// 由当前调用者发起刷新
+			call = &tokenFetchCall{done: make(chan struct{})}
+			ts.inflight = call
+			ts.mu.Unlock()
+
+			call.token, call.err = ts.fetch(ctx)
+			if call.err == nil && (call.token == nil || call.token.AccessToken == "") {
+				call.err = newKindError(ErrUnauthorized, "tokenSource fetch empty token", nil)
+			}
+			call.cancelled = ctx.Err() != nil
+
+			ts.mu.Lock()
+			if call.err == nil {
+				ts.token = call.token
+				ts.failedAt = time.Time{}
+			} else if !call.cancelled {
+				ts.failedAt = time.Now()
+			}
+			ts.inflight = nil
+			ts.mu.Unlock()
+			close(call.done)
+			if call.err != nil {
+				return ts.fallback(call.err)
+			}
+			return call.token, nil
+		}
+		ts.mu.Unlock()
+
+		// 等待正在进行的刷新
+		select {
+		case <-ctx.Done():
+			return nil, newCtxError(ctx.Err())
+		case <-call.done:
+		}
+		if call.err == nil {
+			return call.token, nil
+		}
+		if call.cancelled {
+			// 发起刷新的调用者已取消, 由当前调用者重新发起
+			continue
+		}
+		return ts.fallback(call.err)
+	}
+}
+
+// fallback 刷新失败时, 若缓存的Token尚未过期则继续使用(tokenRefreshFailureBackoff内不再刷新), 否则返回错误的副本
+//
+// 同一次刷新的错误会返回给所有等待者, 复制后调用方修改错误时互不影响
+func (ts *refreshTokenSource) fallback(err error) (*Token, error) {
+	ts.mu.Lock()
+	token := ts.token
+	ts.mu.Unlock()
+	if token.Valid(0) {
+		return token, nil
+	}
+	return nil, cloneError(err)
+}
+
+func (ts *refreshTokenSource) Invalidate(accessToken string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token != nil && ts.token.AccessToken == accessToken {
+		ts.token = nil
+	}
+}
+
+// WithTokenSource 设置accessToken提供者, 设置后SetAccessToken设置的accessToken将不再生效
+//
+// @param ts TokenSource
+func WithTokenSource(ts TokenSource) Option {
+	return func(p123 *Pan123) {
+		p123.tokenSource = ts
+	}
+}
+
+// WithClientCredentials 使用clientID、clientSecret自动获取及刷新accessToken
+//
+// 配合WithTokenStore使用时, 会优先复用TokenStore中保存的accessToken
+//
+// @param clientID string client_id
+//
+// @param clientSecret string client_secret
+func WithClientCredentials(clientID, clientSecret string) Option {
+	return func(p123 *Pan123) {
+		p123.clientID = clientID
+		p123.clientSecret = clientSecret
+	}
+}
+
+func (p123 *Pan123) newClientCredentialsTokenSource() TokenSource {
+	fetch := p123.clientCredentialsFetchFunc(p123.clientID, p123.clientSecret)
+	if p123.tokenStore != nil {
+		return NewStoreTokenSource(p123.tokenStore, fetch, DefaultTokenRefreshBefore)
+	}
+	return NewRefreshTokenSource(fetch, DefaultTokenRefreshBefore)
+}
+
+func (p123 *Pan123) clientCredentialsFetchFunc(clientID, clientSecret string) TokenFetchFunc {
+	return func(ctx context.Context) (*Token, error) {
+		accessToken, expiredAt, err := p123.RequestAccessTokenContext(ctx, clientID, clientSecret)
+		if err != nil {
+			return nil, err
+		}
+		return &Token{AccessToken: accessToken, ExpiredAt: expiredAt}, nil
+	}
+}
+
+func (p123 *Pan123) getAccessToken(ctx context.Context) (string, error) {
+	if p123.tokenSource == nil {
+		return p123.GetAccessToken(), nil
+	}
+	token, err := p123.tokenSource.Token(ctx)
+	if err != nil {
+		var sdkError *SDKError
+		if errors.As(err, &sdkError) {
+			return "", err
+		}
+		return "", newKindError(ErrUnauthorized, fmt.Sprintf("tokenSource.Token error: %s", err), err)
+	}
+	return token.AccessToken, nil
+}
+
+// isUnauthorizedError 接口返回code 401或HTTP 401
+func isUnauthorizedError(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
@@ -0,0 +1,366 @@
+package pan123
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// 锁文件超过该时间未刷新视为持有者已退出
+	fileTokenStoreStaleLock = time.Minute
+	// 持有锁期间刷新锁文件修改时间的间隔, 需远小于fileTokenStoreStaleLock
+	fileTokenStoreLockRefresh = 10 * time.Second
+	// 获取锁文件失败时的轮询间隔
+	fileTokenStoreLockPoll = 50 * time.Millisecond
+)
+
+// TokenStore Token持久化存储, 用于在多个Pan123实例或多个进程间共享同一个accessToken
+type TokenStore interface {
+	// Load 读取Token, 不存在时返回nil, nil
+	Load(ctx context.Context) (*Token, error)
+	// Save 保存Token
+	Save(ctx context.Context, token *Token) error
+	// Lock 获取锁, 持有锁期间其他调用者(包括其他进程)无法刷新Token, 返回用于释放锁的函数
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// WithTokenStore 设置Token持久化存储, 需配合WithClientCredentials使用
+//
+// @param store TokenStore 例如: NewMemoryTokenStore、NewFileTokenStore
+func WithTokenStore(store TokenStore) Option {
+	return func(p123 *Pan123) {
+		p123.tokenStore = store
+	}
+}
+
+type storeTokenSource struct {
+	TokenSource
+
+	store         TokenStore
+	fetch         TokenFetchFunc
+	refreshBefore time.Duration
+
+	mu      sync.Mutex
+	invalid string
+}
+
+// NewStoreTokenSource 创建基于TokenStore的自动刷新TokenSource
+//
+// 刷新时优先读取store中的Token, 仅在store中的Token无效时才调用fetch, 并将结果写回store
+//
+// @param store TokenStore Token持久化存储
+//
+// @param fetch TokenFetchFunc 获取新Token的方法
+//
+// @param refreshBefore time.Duration 在过期前多久进行刷新, 小于0时使用DefaultTokenRefreshBefore
+//
+// @return TokenSource
+func NewStoreTokenSource(store TokenStore, fetch TokenFetchFunc, refreshBefore time.Duration) TokenSource {
+	if refreshBefore < 0 {
+		refreshBefore = DefaultTokenRefreshBefore
+	}
+	ts := &storeTokenSource{
+		store:         store,
+		fetch:         fetch,
+		refreshBefore: refreshBefore,
+	}
+	ts.TokenSource = NewRefreshTokenSource(ts.fetchFromStore, refreshBefore)
+	return ts
+}
+
+func (ts *storeTokenSource) Invalidate(accessToken string) {
+	ts.mu.Lock()
+	ts.invalid = accessToken
+	ts.mu.Unlock()
+	ts.TokenSource.Invalidate(accessToken)
+}
+
+func (ts *storeTokenSource) usable(token *Token) bool {
+	if !token.Valid(ts.refreshBefore) {
+		return false
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return token.AccessToken != ts.invalid
+}
+
+func (ts *storeTokenSource) fetchFromStore(ctx context.Context) (*Token, error) {
+	if token, err := ts.store.Load(ctx); err == nil && ts.usable(token) {
+		return token, nil
+	}
+
+	unlock, err := ts.store.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// 等待锁期间可能已有其他进程完成刷新
+	if token, err := ts.store.Load(ctx); err == nil && ts.usable(token) {
+		return token, nil
+	}
+	token, err := ts.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 保存失败不影响本次获取的Token
+	_ = ts.store.Save(ctx, token)
+
+	return token, nil
+}
+
+// MemoryTokenStore 进程内Token存储, 可在多个Pan123实例间共享
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *Token
+	sem   chan struct{}
+}
+
+// NewMemoryTokenStore 创建进程内Token存储
+//
+// @return *MemoryTokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{sem: make(chan struct{}, 1)}
+}
+
+// Load 读取Token
+func (s *MemoryTokenStore) Load(_ context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == nil {
+		return nil, nil
+	}
+	token := *s.token
+	return &token, nil
+}
+
+// Save 保存Token
+func (s *MemoryTokenStore) Save(_ context.Context, token *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token == nil {
+		s.token = nil
+		return nil
+	}
+	_token := *token
+	s.token = &_token
+	return nil
+}
+
+// Lock 获取锁
+func (s *MemoryTokenStore) Lock(ctx context.Context) (func(), error) {
+	select {
+	case s.sem <- struct{}{}:
+		return func() { <-s.sem }, nil
+	case <-ctx.Done():
+		return nil, newCtxError(ctx.Err())
+	}
+}
+
+// FileTokenStore 基于文件的Token存储, 可在同一主机的多个进程间共享
+//
+// Token以JSON格式保存, 文件权限为0600, 写入时先写临时文件再重命名以保证原子性;
+// 跨进程锁通过同目录下的 .lock 文件实现
+type FileTokenStore struct {
+	path string
+}
+
+// NewFileTokenStore 创建基于文件的Token存储
+//
+// 不同clientID请使用不同的文件
+//
+// @param path string Token文件路径
+//
+// @return *FileTokenStore
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Load 读取Token, 文件不存在时返回nil, nil
+func (s *FileTokenStore) Load(_ context.Context) (*Token, error) {
+	buf, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, newKindError(ErrFileIO, fmt.Sprintf("ioutil.ReadFile(tokenFile) error: %s", err), err)
+	}
+	var token Token
+	if err := json.Unmarshal(buf, &token); err != nil {
+		return nil, newKindError(ErrDecode, fmt.Sprintf("json.Unmarshal(tokenFile) error: %s", err), err)
+	}
+	return &token, nil
+}
+
+// Save 原子写入Token
+func (s *FileTokenStore) Save(_ context.Context, token *Token) error {
+	buf, err := json.Marshal(token)
+	if err != nil {
+		return newKindError(ErrValidation, fmt.Sprintf("json.Marshal(token) error: %s", err), err)
+	}
+
+	return writeFileAtomic(s.path, buf)
+}
+
+// writeFileAtomic 先写临时文件再重命名, 文件权限为0600
+func writeFileAtomic(path string, buf []byte) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, base+".tmp*")
+	if err != nil {
+		return newKindError(ErrFileIO, fmt.Sprintf("ioutil.TempFile error: %s", err), err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		// 重命名成功后临时文件已不存在
+		_ = os.Remove(tmpPath)
+	}()
+
+	if err := tmp.Chmod(0600); err != nil {
+		_ = tmp.Close()
+		return newKindError(ErrFileIO, fmt.Sprintf("tmp.Chmod error: %s", err), err)
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		_ = tmp.Close()
+		return newKindError(ErrFileIO, fmt.Sprintf("tmp.Write error: %s", err), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return newKindError(ErrFileIO, fmt.Sprintf("tmp.Sync error: %s", err), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return newKindError(ErrFileIO, fmt.Sprintf("tmp.Close error: %s", err), err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return newKindError(ErrFileIO, fmt.Sprintf("os.Rename error: %s", err), err)
+	}
+
+	return nil
+}
+
+// Lock 通过创建 .lock 文件获取跨进程锁
+//
+// 锁文件中记录持有者的pid及随机标识, 持有期间每10秒刷新一次修改时间;
+// 超过1分钟未刷新的锁文件视为持有者已退出, 可被其他调用者获取; 释放时仅删除自己持有的锁文件
+func (s *FileTokenStore) Lock(ctx context.Context) (func(), error) {
+	lockPath := s.path + ".lock"
+	owner, err := newLockOwner()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, writeErr := f.WriteString(owner)
+			_ = f.Close()
+			if writeErr != nil {
+				_ = os.Remove(lockPath)
+				return nil, newKindError(ErrFileIO, fmt.Sprintf("lockFile.Write error: %s", writeErr), writeErr)
+			}
+			return s.holdLock(lockPath, owner), nil
+		}
+		if !os.IsExist(err) {
+			return nil, newKindError(ErrFileIO, fmt.Sprintf("os.OpenFile(lockFile) error: %s", err), err)
+		}
+		if s.removeStaleLock(lockPath) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, newCtxError(ctx.Err())
+		case <-time.After(fileTokenStoreLockPoll):
+		}
+	}
+}
+
+// newLockOwner 生成锁文件内容: pid及随机标识
+func newLockOwner() (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", newKindError(ErrFileIO, fmt.Sprintf("rand.Read error: %s", err), err)
+	}
+	return fmt.Sprintf("%d %x", os.Getpid(), nonce), nil
+}
+
+// holdLock 持有期间定期刷新锁文件的修改时间, 返回释放锁的函数
+func (s *FileTokenStore) holdLock(lockPath, owner string) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(fileTokenStoreLockRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if lockOwnedBy(lockPath, owner) {
+					now := time.Now()
+					_ = os.Chtimes(lockPath, now, now)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+			// 锁文件可能已被视为失效并由其他调用者持有
+			if lockOwnedBy(lockPath, owner) {
+				_ = os.Remove(lockPath)
+			}
+		})
+	}
+}
+
+// removeStaleLock 删除失效的锁文件, 成功删除时返回true
+//
+// 先将锁文件重命名为唯一的临时文件再核对内容, 避免多个调用者同时判定失效时误删其他调用者新建的锁文件
+func (s *FileTokenStore) removeStaleLock(lockPath string) bool {
+	fi, err := os.Stat(lockPath)
+	if err != nil || time.Since(fi.ModTime()) <= fileTokenStoreStaleLock {
+		return false
+	}
+	stale, err := ioutil.ReadFile(lockPath)
+	if err != nil {
+		return false
+	}
+
+	owner, err := newLockOwner()
+	if err != nil {
+		return false
+	}
+	tmpPath := fmt.Sprintf("%s.stale.%s", lockPath, strings.Replace(owner, " ", "-", 1))
+	if err := os.Rename(lockPath, tmpPath); err != nil {
+		return false
+	}
+	if buf, err := ioutil.ReadFile(tmpPath); err != nil || !bytes.Equal(buf, stale) {
+		// 重命名前锁文件已被其他调用者替换, 还原
+		_ = os.Link(tmpPath, lockPath)
+		_ = os.Remove(tmpPath)
+		return false
+	}
+	_ = os.Remove(tmpPath)
+	return true
+}
+
+// lockOwnedBy 锁文件是否由owner持有
+func lockOwnedBy(lockPath, owner string) bool {
+	buf, err := ioutil.ReadFile(lockPath)
+	return err == nil && string(buf) == owner
+}
@@ -0,0 +1,143 @@
+package pan123
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileTokenStoreSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	store := NewFileTokenStore(path)
+	ctx := context.Background()
+
+	token, err := store.Load(ctx)
+	if err != nil || token != nil {
+		t.Fatalf("Load() on missing file = %v, %v", token, err)
+	}
+
+	want := &Token{AccessToken: "token", ExpiredAt: time.Now().Add(time.Hour).Truncate(time.Second)}
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessToken != want.AccessToken || !got.ExpiredAt.Equal(want.ExpiredAt) {
+		t.Fatalf("Load() = %+v, want %+v", got, want)
+	}
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := fi.Mode().Perm(); perm != 0600 {
+			t.Fatalf("perm = %o, want 600", perm)
+		}
+	}
+}
+
+func TestFileTokenStoreLock(t *testing.T) {
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	unlock, err := store.Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx); err == nil {
+		t.Fatal("second Lock() should wait until ctx is done")
+	}
+
+	unlock()
+	unlock2, err := store.Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock2()
+}
+
+func TestFileTokenStoreStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	lockPath := path + ".lock"
+	store := NewFileTokenStore(path)
+
+	// 其他进程持有且仍在刷新的锁不可获取
+	if err := os.WriteFile(lockPath, []byte("1 other"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx); err == nil {
+		t.Fatal("Lock() should not steal a live lock")
+	}
+
+	// 超时未刷新的锁视为失效
+	old := time.Now().Add(-2 * fileTokenStoreStaleLock)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := store.Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 锁被其他进程接管后, 释放时不删除其他进程的锁文件
+	if err := os.WriteFile(lockPath, []byte("2 other"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if buf, err := os.ReadFile(lockPath); err != nil || string(buf) != "2 other" {
+		t.Fatalf("lock file = %q, %v", buf, err)
+	}
+}
+
+func TestTokenStoreSharedBetweenClients(t *testing.T) {
+	var issued int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/access_token":
+			n := atomic.AddInt32(&issued, 1)
+			expiredAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+			_, _ = fmt.Fprintf(w, `{"code":0,"message":"ok","data":{"accessToken":"token-%d","expiredAt":%q}}`, n, expiredAt)
+		default:
+			_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{"uid":1}}`))
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "token.json")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个实例使用独立的FileTokenStore, 模拟多个进程
+			p123 := NewPan123WithOptions(
+				WithBaseURL(srv.URL),
+				WithClientCredentials("id", "secret"),
+				WithTokenStore(NewFileTokenStore(path)),
+				WithoutRateLimit(),
+			)
+			if _, err := p123.GetUserInfo(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&issued); n != 1 {
+		t.Fatalf("access_token called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,186 @@
+package pan123
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenSourceCollapsesConcurrentRefresh(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	ts := NewRefreshTokenSource(func(ctx context.Context) (*Token, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return &Token{AccessToken: "token", ExpiredAt: time.Now().Add(time.Hour)}, nil
+	}, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := ts.Token(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if token.AccessToken != "token" {
+				t.Errorf("AccessToken = %q", token.AccessToken)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("fetch called %d times, want 1", n)
+	}
+}
+
+func TestRefreshTokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	var fetches int32
+	ts := NewRefreshTokenSource(func(ctx context.Context) (*Token, error) {
+		n := atomic.AddInt32(&fetches, 1)
+		// 第一次返回的Token处于提前刷新区间内
+		expiredAt := time.Now().Add(30 * time.Second)
+		if n > 1 {
+			expiredAt = time.Now().Add(time.Hour)
+		}
+		return &Token{AccessToken: fmt.Sprintf("token-%d", n), ExpiredAt: expiredAt}, nil
+	}, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := ts.Token(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	token, _ := ts.Token(context.Background())
+	if token.AccessToken != "token-2" {
+		t.Fatalf("AccessToken = %q, want token-2", token.AccessToken)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Fatalf("fetch called %d times, want 2", n)
+	}
+}
+
+func TestRefreshTokenSourceKeepsCachedTokenOnError(t *testing.T) {
+	var fetches int32
+	ts := NewRefreshTokenSource(func(ctx context.Context) (*Token, error) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			// 处于提前刷新区间内, 但尚未过期
+			return &Token{AccessToken: "token", ExpiredAt: time.Now().Add(30 * time.Second)}, nil
+		}
+		return nil, newKindError(ErrTransport, "http error: connection refused", nil)
+	}, time.Minute)
+
+	for i := 0; i < 5; i++ {
+		token, err := ts.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != "token" {
+			t.Fatalf("AccessToken = %q", token.AccessToken)
+		}
+	}
+	// 刷新失败后tokenRefreshFailureBackoff内不再刷新
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Fatalf("fetch called %d times, want 2", n)
+	}
+	// 超过tokenRefreshFailureBackoff后再次尝试刷新
+	rts := ts.(*refreshTokenSource)
+	rts.mu.Lock()
+	rts.failedAt = time.Now().Add(-tokenRefreshFailureBackoff)
+	rts.mu.Unlock()
+	if _, err := ts.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 3 {
+		t.Fatalf("fetch called %d times, want 3", n)
+	}
+
+	// 已失效的Token不再使用
+	ts.Invalidate("token")
+	if _, err := ts.Token(context.Background()); !errors.Is(err, ErrTransport) {
+		t.Fatalf("err = %v, want ErrTransport", err)
+	}
+}
+
+func TestRefreshTokenSourceErrorPerWaiter(t *testing.T) {
+	release := make(chan struct{})
+	ts := NewRefreshTokenSource(func(ctx context.Context) (*Token, error) {
+		<-release
+		return nil, newKindError(ErrTransport, "http error: connection refused", nil)
+	}, time.Minute)
+
+	errs := make([]error, 8)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = ts.Token(context.Background())
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	seen := make(map[error]bool)
+	for _, err := range errs {
+		if !errors.Is(err, ErrTransport) {
+			t.Fatalf("err = %v, want ErrTransport", err)
+		}
+		if seen[err] {
+			t.Fatal("waiters share the same error value")
+		}
+		seen[err] = true
+	}
+}
+
+func TestClientCredentialsRefreshOnUnauthorized(t *testing.T) {
+	// 接口返回code 401及HTTP 401均触发刷新
+	for _, httpStatus := range []bool{false, true} {
+		var issued, mkdirs int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v1/access_token":
+				n := atomic.AddInt32(&issued, 1)
+				expiredAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+				_, _ = fmt.Fprintf(w, `{"code":0,"message":"ok","data":{"accessToken":"token-%d","expiredAt":%q}}`, n, expiredAt)
+			case "/upload/v1/file/mkdir":
+				atomic.AddInt32(&mkdirs, 1)
+				if r.Header.Get("Authorization") != "Bearer token-2" {
+					if httpStatus {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					_, _ = w.Write([]byte(`{"code":401,"message":"token is expired","x-traceID":"t"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{"dirID":1}}`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+
+		p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithClientCredentials("id", "secret"), WithoutRateLimit())
+		if _, err := p123.MkDir("dir", 0); err != nil {
+			t.Fatalf("httpStatus=%v: %s", httpStatus, err)
+		}
+		if n := atomic.LoadInt32(&issued); n != 2 {
+			t.Errorf("httpStatus=%v: access_token called %d times, want 2", httpStatus, n)
+		}
+		if n := atomic.LoadInt32(&mkdirs); n != 2 {
+			t.Errorf("httpStatus=%v: mkdir called %d times, want 2", httpStatus, n)
+		}
+		srv.Close()
+	}
+}
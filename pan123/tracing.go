@@ -0,0 +1,226 @@
+package pan123
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Span属性名
+const (
+	// 123云盘接口返回的x-traceID
+	AttrTraceID = "pan123.trace_id"
+	// 接口路径, 例如: /api/v1/file/move
+	AttrEndpoint = "pan123.endpoint"
+	// 接口请求次数(含重试)
+	AttrAttempts = "pan123.attempts"
+	// SDKError.Code
+	AttrErrorCode = "pan123.error_code"
+	// 预上传ID
+	AttrPreuploadID = "pan123.preupload_id"
+	// 分块序号, 从1开始
+	AttrSliceNo = "pan123.slice_no"
+	// 文件或分块大小
+	AttrSize = "pan123.size"
+	// 上传的文件名
+	AttrFilename = "pan123.filename"
+	// 父目录ID
+	AttrParentFileID = "pan123.parent_file_id"
+	// 上传完成后的文件ID
+	AttrFileID = "pan123.file_id"
+	// HTTP方法
+	AttrHTTPMethod = "http.method"
+	// HTTP状态码
+	AttrHTTPStatusCode = "http.status_code"
+)
+
+// Attribute Span属性
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Attr 创建Span属性
+//
+// @param key string 属性名, 例如: pan123.trace_id
+//
+// @param value interface{} 属性值, 为string、int64、int、bool、float64之一
+//
+// @return Attribute
+func Attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer 链路追踪接口, 实现该接口即可接入OpenTelemetry等链路追踪系统
+//
+// SDK为每次JSON接口调用、每次分块上传创建Span, 并将其嵌套在每次文件上传的Span下;
+// 父Span通过ctx传递, 调用方ctx中已有的Span(例如OpenTelemetry的Span)由Tracer的实现自行处理
+type Tracer interface {
+	// Start 创建Span, 返回携带新Span的ctx
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span 链路追踪中的一个操作
+type Span interface {
+	// SetAttributes 设置属性
+	SetAttributes(attrs ...Attribute)
+	// RecordError 记录错误, 并将Span标记为失败
+	RecordError(err error)
+	// End 结束Span
+	End()
+}
+
+// WithTracer 设置链路追踪, 默认不追踪
+//
+// @param tracer Tracer
+func WithTracer(tracer Tracer) Option {
+	return func(p123 *Pan123) {
+		p123.tracer = tracer
+	}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// RecordedSpan TraceRecorder记录的Span
+type RecordedSpan struct {
+	// 从1开始递增
+	ID int64
+	// 父Span的ID, 根Span为0
+	ParentID   int64
+	Name       string
+	Attributes map[string]interface{}
+	Err        error
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+// TraceRecorder 在内存中记录Span的Tracer, 用于测试, 并发安全
+type TraceRecorder struct {
+	mu     sync.Mutex
+	nextID int64
+	spans  []*recordedSpan
+}
+
+type recordedSpan struct {
+	r     *TraceRecorder
+	span  RecordedSpan
+	ended bool
+}
+
+type traceRecorderCtxKey struct{}
+
+// NewTraceRecorder 创建TraceRecorder
+//
+// @return *TraceRecorder
+func NewTraceRecorder() *TraceRecorder {
+	return &TraceRecorder{}
+}
+
+// Start 实现Tracer
+func (r *TraceRecorder) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	s := &recordedSpan{r: r, span: RecordedSpan{
+		ID:         r.nextID,
+		Name:       name,
+		Attributes: map[string]interface{}{},
+		StartTime:  time.Now(),
+	}}
+	if parent, ok := ctx.Value(traceRecorderCtxKey{}).(*recordedSpan); ok && parent.r == r {
+		s.span.ParentID = parent.span.ID
+	}
+	for _, attr := range attrs {
+		s.span.Attributes[attr.Key] = attr.Value
+	}
+	r.spans = append(r.spans, s)
+	return context.WithValue(ctx, traceRecorderCtxKey{}, s), s
+}
+
+// Spans 已结束的Span, 按创建顺序
+//
+// @return []RecordedSpan
+func (r *TraceRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]RecordedSpan, 0, len(r.spans))
+	for _, s := range r.spans {
+		if !s.ended {
+			continue
+		}
+		span := s.span
+		span.Attributes = make(map[string]interface{}, len(s.span.Attributes))
+		for k, v := range s.span.Attributes {
+			span.Attributes[k] = v
+		}
+		out = append(out, span)
+	}
+	return out
+}
+
+// Reset 清空已记录的Span
+func (r *TraceRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attribute) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	for _, attr := range attrs {
+		s.span.Attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	s.span.Err = err
+}
+
+func (s *recordedSpan) End() {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+	s.span.EndTime = time.Now()
+}
+
+// endAPISpan 为JSON接口调用的Span补充x-traceID等属性并结束
+func endAPISpan(span Span, meta *ResponseMeta, err error) {
+	if err != nil {
+		var sdkError *SDKError
+		if errors.As(err, &sdkError) {
+			attrs := []Attribute{Attr(AttrErrorCode, sdkError.Code)}
+			if sdkError.TraceID != "" && sdkError.TraceID != defaultTraceID {
+				attrs = append(attrs, Attr(AttrTraceID, sdkError.TraceID))
+			}
+			if sdkError.HTTPStatus != 0 {
+				attrs = append(attrs, Attr(AttrHTTPStatusCode, sdkError.HTTPStatus))
+			}
+			span.SetAttributes(attrs...)
+		}
+		span.RecordError(err)
+	} else if meta != nil {
+		attrs := []Attribute{Attr(AttrHTTPStatusCode, meta.HTTPStatus), Attr(AttrAttempts, meta.Attempts)}
+		if meta.TraceID != defaultTraceID {
+			attrs = append(attrs, Attr(AttrTraceID, meta.TraceID))
+		}
+		span.SetAttributes(attrs...)
+	}
+	span.End()
+}
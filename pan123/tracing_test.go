@@ -0,0 +1,157 @@
+package pan123
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/123pan-3rd/go-sdk/v2/pan123/pan123test"
+)
+
+func TestTracingUploadSpans(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	recorder := NewTraceRecorder()
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithTracer(recorder))
+	p123.SetAccessToken("token")
+
+	path := filepath.Join(t.TempDir(), "upload.txt")
+	if err := os.WriteFile(path, []byte("0123456789"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	resp, err := p123.FileUpload(0, "upload.txt", file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Spans()
+	var root RecordedSpan
+	for _, span := range spans {
+		if span.ParentID == 0 {
+			if root.ID != 0 {
+				t.Fatalf("multiple root spans: %+v", spans)
+			}
+			root = span
+		}
+	}
+	if root.Name != "pan123 FileUpload" || root.Attributes[AttrFileID] != resp.FileID || root.Err != nil {
+		t.Fatalf("root span = %+v", root)
+	}
+
+	var apiSpans, chunkSpans int
+	for _, span := range spans {
+		if span.ID == root.ID {
+			continue
+		}
+		if span.ParentID != root.ID {
+			t.Errorf("span %s not nested under upload span", span.Name)
+		}
+		switch {
+		case span.Name == "PUT chunk":
+			chunkSpans++
+			if span.Attributes[AttrHTTPStatusCode] != 200 || span.Attributes[AttrSize] == nil {
+				t.Errorf("chunk span attributes = %v", span.Attributes)
+			}
+		case strings.HasPrefix(span.Name, "POST /upload/"):
+			apiSpans++
+			if traceID, _ := span.Attributes[AttrTraceID].(string); !strings.HasPrefix(traceID, "fake-trace-") {
+				t.Errorf("span %s trace id = %v", span.Name, span.Attributes[AttrTraceID])
+			}
+		default:
+			t.Errorf("unexpected span %s", span.Name)
+		}
+		if span.EndTime.Before(span.StartTime) {
+			t.Errorf("span %s ended before start", span.Name)
+		}
+	}
+	// 10字节/4字节分块: 3个分块, create + 3*get_upload_url + list_upload_parts + upload_complete
+	if chunkSpans != 3 || apiSpans != 6 {
+		t.Errorf("chunk spans = %d, api spans = %d", chunkSpans, apiSpans)
+	}
+}
+
+func TestTracingAPIError(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	recorder := NewTraceRecorder()
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithTracer(recorder))
+	p123.SetAccessToken("token")
+
+	srv.FailNextWithCode("/api/v1/file/detail", 1, pan123test.CodeNotFound, "not found")
+	if _, err := p123.GetFileDetail(1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+	spans := recorder.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %+v", spans)
+	}
+	span := spans[0]
+	if span.Name != "GET /api/v1/file/detail" || !errors.Is(span.Err, ErrNotFound) {
+		t.Errorf("span = %+v", span)
+	}
+	if span.Attributes[AttrErrorCode] != pan123test.CodeNotFound || span.Attributes[AttrTraceID] == nil {
+		t.Errorf("span attributes = %v", span.Attributes)
+	}
+}
+
+func TestTracingChunkErrors(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	recorder := NewTraceRecorder()
+	// 第1块首次返回nil响应, 第2块首次返回HTTP 500, 重试后成功
+	var attempts sync.Map
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithTracer(recorder),
+		WithChunkMiddleware(func(next ChunkHandler) ChunkHandler {
+			return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+				if _, retried := attempts.LoadOrStore(req.SliceNo, true); !retried {
+					switch req.SliceNo {
+					case 1:
+						return nil, nil
+					case 2:
+						return &ChunkResponse{StatusCode: 500}, nil
+					}
+				}
+				return next(ctx, req)
+			}
+		}))
+	p123.SetAccessToken("token")
+
+	content := []byte("0123456789")
+	if _, err := p123.FileUploadFromReaderAt(0, "chunk-errors.txt", bytes.NewReader(content), int64(len(content)), 1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var failed []RecordedSpan
+	for _, span := range recorder.Spans() {
+		if span.Name == "PUT chunk" && span.Err != nil {
+			failed = append(failed, span)
+		}
+	}
+	if len(failed) != 2 {
+		t.Fatalf("failed chunk spans = %+v", failed)
+	}
+	for _, span := range failed {
+		switch span.Attributes[AttrSliceNo] {
+		case int64(1):
+			if !errors.Is(span.Err, ErrUploadFailed) {
+				t.Errorf("slice 1 span err = %v, want ErrUploadFailed", span.Err)
+			}
+		case int64(2):
+			if !errors.Is(span.Err, ErrHTTPStatus) || span.Attributes[AttrHTTPStatusCode] != 500 {
+				t.Errorf("slice 2 span = %+v", span)
+			}
+		default:
+			t.Errorf("unexpected failed span %+v", span)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package pan123
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Timeouts HTTP请求各阶段的超时时间, 为0时不限制
+//
+// Dial、TLSHandshake、ResponseHeader作用于SDK创建的默认Transport, 使用WithTransport或WithHTTPClient时不生效;
+// Request、Chunk通过ctx实现, 始终生效
+//
+// 默认不设置任何超时(与NewPan123原有行为一致), 可通过WithTimeouts(RecommendedTimeouts())启用
+type Timeouts struct {
+	// 建立TCP连接
+	Dial time.Duration
+	// TLS握手
+	TLSHandshake time.Duration
+	// 发送请求(含请求体)后等待响应头
+	ResponseHeader time.Duration
+	// 单次JSON接口请求的总耗时(含读取响应体), 重试时重新计时
+	Request time.Duration
+	// 单个分块上传的总耗时(含发送分块数据), 重试时重新计时; 需按分块大小及带宽设置
+	Chunk time.Duration
+}
+
+// RecommendedTimeouts 推荐的超时时间: 连接30s, TLS握手10s, 等待响应头60s, 接口请求2分钟, 分块上传10分钟
+//
+// 分块上传10分钟按不低于约160KiB/s的上传带宽估算(分块大小通常为16MiB), 带宽较低时请调大Chunk
+//
+// @return Timeouts
+func RecommendedTimeouts() Timeouts {
+	return Timeouts{
+		Dial:           30 * time.Second,
+		TLSHandshake:   10 * time.Second,
+		ResponseHeader: 60 * time.Second,
+		Request:        2 * time.Minute,
+		Chunk:          10 * time.Minute,
+	}
+}
+
+// WithTimeouts 设置HTTP请求各阶段的超时时间, 例如: RecommendedTimeouts()
+//
+// 超时返回的错误属于ErrTransport, 可按RetryPolicy重试; 调用方ctx的取消与超时仍返回ErrCanceled
+//
+// @param timeouts Timeouts
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(p123 *Pan123) {
+		p123.timeouts = timeouts
+	}
+}
+
+// ConnPool 默认Transport的连接池配置, 使用WithTransport或WithHTTPClient时不生效
+type ConnPool struct {
+	// TCP keep-alive探测间隔, 小于0时关闭keep-alive
+	KeepAlive time.Duration
+	// 所有host的最大空闲连接数, 0为不限制
+	MaxIdleConns int
+	// 单个host的最大空闲连接数, 0时使用http.DefaultMaxIdleConnsPerHost(2)
+	MaxIdleConnsPerHost int
+	// 单个host的最大连接数(含使用中的连接), 0为不限制
+	MaxConnsPerHost int
+	// 空闲连接的保留时间, 0为不限制
+	IdleConnTimeout time.Duration
+}
+
+// DefaultConnPool 默认的连接池配置, 单个host保留16个空闲连接, 满足并发上传分块的需要
+//
+// @return ConnPool
+func DefaultConnPool() ConnPool {
+	return ConnPool{
+		KeepAlive:           30 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// WithConnPool 设置默认Transport的连接池配置, 覆盖DefaultConnPool
+//
+// @param pool ConnPool
+func WithConnPool(pool ConnPool) Option {
+	return func(p123 *Pan123) {
+		p123.connPool = pool
+	}
+}
+
+func newDefaultTransport(timeouts Timeouts, pool ConnPool) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   timeouts.Dial,
+		KeepAlive: pool.KeepAlive,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   timeouts.TLSHandshake,
+		ResponseHeaderTimeout: timeouts.ResponseHeader,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          pool.MaxIdleConns,
+		MaxIdleConnsPerHost:   pool.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       pool.MaxConnsPerHost,
+		IdleConnTimeout:       pool.IdleConnTimeout,
+	}
+}
+
+// contextWithTimeout timeout为0时不限制
+func contextWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// newTimeoutAwareTransportError 区分SDK设置的超时(ErrTransport)与调用方ctx的取消或超时(ErrCanceled)
+func newTimeoutAwareTransportError(ctx context.Context, err error, timeout time.Duration) error {
+	if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return newKindError(ErrTransport, fmt.Sprintf("http error: timeout after %s: %s", timeout, err), err)
+	}
+	return newTransportError(err)
+}
@@ -0,0 +1,123 @@
+package pan123
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stallServer 收到请求后不响应, 直至客户端断开
+func stallServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 读完请求体后才能感知客户端断开
+		_, _ = io.Copy(ioutil.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+}
+
+func TestRequestTimeout(t *testing.T) {
+	srv := stallServer()
+	defer srv.Close()
+
+	timeouts := RecommendedTimeouts()
+	timeouts.Request = 50 * time.Millisecond
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithTimeouts(timeouts), WithoutRateLimit())
+	p123.SetAccessToken("token")
+
+	start := time.Now()
+	_, err := p123.GetUserInfo()
+	if !errors.Is(err, ErrTransport) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want ErrTransport wrapping DeadlineExceeded", err)
+	}
+	var sdkErr *SDKError
+	if !errors.As(err, &sdkErr) || !sdkErr.Retryable() {
+		t.Errorf("timeout should be retryable: %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("request took %s", d)
+	}
+
+	// 调用方ctx超时仍为ErrCanceled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p123.GetUserInfoContext(ctx); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("err = %v, want ErrCanceled", err)
+	}
+}
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	srv := stallServer()
+	defer srv.Close()
+
+	timeouts := RecommendedTimeouts()
+	timeouts.ResponseHeader = 50 * time.Millisecond
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithTimeouts(timeouts), WithoutRateLimit())
+	p123.SetAccessToken("token")
+
+	if _, err := p123.GetUserInfo(); !errors.Is(err, ErrTransport) || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("err = %v, want response header timeout", err)
+	}
+}
+
+func TestChunkTimeout(t *testing.T) {
+	srv := stallServer()
+	defer srv.Close()
+
+	timeouts := RecommendedTimeouts()
+	timeouts.Chunk = 50 * time.Millisecond
+	p123 := NewPan123WithOptions(WithTimeouts(timeouts))
+
+	start := time.Now()
+	_, err := p123.chunkHandler(context.Background(), &ChunkRequest{
+		SliceNo: 1,
+		URL:     srv.URL + "/chunk?signature=secret",
+		Size:    4,
+		Attempt: 1,
+		Header:  map[string]string{},
+		Body:    strings.NewReader("data"),
+	})
+	if !errors.Is(err, ErrTransport) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want ErrTransport wrapping DeadlineExceeded", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("error leaks signature: %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("chunk upload took %s", d)
+	}
+}
+
+func TestDefaultTransportConfig(t *testing.T) {
+	pool := DefaultConnPool()
+	pool.MaxConnsPerHost = 8
+	p123 := NewPan123WithOptions(WithTimeouts(RecommendedTimeouts()), WithTimeout(5*time.Second), WithConnPool(pool))
+	transport, ok := p123.transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport = %T", p123.transport)
+	}
+	if transport.MaxIdleConnsPerHost != 16 || transport.MaxConnsPerHost != 8 || transport.IdleConnTimeout != 90*time.Second {
+		t.Errorf("pool config not applied: %+v", transport)
+	}
+	if transport.TLSHandshakeTimeout != 10*time.Second || transport.ResponseHeaderTimeout != 60*time.Second {
+		t.Errorf("timeouts not applied: tls=%s header=%s", transport.TLSHandshakeTimeout, transport.ResponseHeaderTimeout)
+	}
+	if p123.timeouts.Dial != 5*time.Second {
+		t.Errorf("dial timeout = %s", p123.timeouts.Dial)
+	}
+
+	// 默认不设置任何超时, NewPan123(0, ...)与原有行为一致
+	p := NewPan123(0, false)
+	if p.timeouts != (Timeouts{}) {
+		t.Errorf("default timeouts = %+v", p.timeouts)
+	}
+	transport = p.transport.(*http.Transport)
+	if transport.TLSHandshakeTimeout != 0 || transport.ResponseHeaderTimeout != 0 {
+		t.Errorf("default transport timeouts: tls=%s header=%s", transport.TLSHandshakeTimeout, transport.ResponseHeaderTimeout)
+	}
+}
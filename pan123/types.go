@@ -1,14 +1,19 @@
 package pan123
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type apiHttpResp struct {
-	Code    int                    `json:"code"`
-	Message string                 `json:"message"`
-	TraceID string                 `json:"x-traceID"`
-	Data    map[string]interface{} `json:"data"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	TraceID string          `json:"x-traceID"`
+	Data    json.RawMessage `json:"data"`
 }
 
 type callApiResp struct {
-	Data map[string]interface{}
+	Data json.RawMessage
 }
 
 type loginRespData struct {
@@ -241,6 +246,24 @@ type FileUploadCallbackInfo struct {
 	ChunkID int64
 	// 总chunk数量, 仅在FILE_UPLOAD_CALLBACK_STATUS_FIRST_UPLOAD_CHUNK/FILE_UPLOAD_CALLBACK_STATUS_RETRY_UPLOAD_CHUNK/FILE_UPLOAD_CALLBACK_STATUS_VERIFY_CHUNK时存在
 	ChunkCount int64
+
+	// 以下字段在所有状态下均存在
+
+	// 文件总字节数, UploadStream暂存完成前为0
+	TotalBytes int64
+	// 计算md5已读取的字节数
+	HashedBytes int64
+	// 已发送的分块字节数(含续传时已上传的分块), 分块上传失败时减去该分块已发送的字节数
+	SentBytes int64
+	// 平滑后的上传速度, 单位为字节/秒, 开始发送分块前为0
+	BytesPerSecond float64
+	// 按BytesPerSecond估算的剩余上传时间, 无法估算时为0
+	ETA time.Duration
+
+	// 上传结果, 仅在FILE_UPLOAD_CALLBACK_STATUS_DONE且上传成功时存在
+	Result *FileUploadRespData
+	// 上传失败的原因, 仅在FILE_UPLOAD_CALLBACK_STATUS_DONE且上传失败时存在
+	Err error
 }
 
 type FileUploadCallbackFunc func(info FileUploadCallbackInfo)
@@ -258,8 +281,14 @@ const (
 	FILE_UPLOAD_CALLBACK_STATUS_VERIFY_CHUNK
 	// FILE_UPLOAD_CALLBACK_STATUS_REPORT_COMPLETE 通知上传完成(文件合并)
 	FILE_UPLOAD_CALLBACK_STATUS_REPORT_COMPLETE
+	// FILE_UPLOAD_CALLBACK_STATUS_HASH_PROGRESS 计算md5的进度
+	FILE_UPLOAD_CALLBACK_STATUS_HASH_PROGRESS
+	// FILE_UPLOAD_CALLBACK_STATUS_UPLOAD_PROGRESS 分块发送的进度
+	FILE_UPLOAD_CALLBACK_STATUS_UPLOAD_PROGRESS
+	// FILE_UPLOAD_CALLBACK_STATUS_DONE 上传结束(成功或失败), 为最后一次Callback
+	FILE_UPLOAD_CALLBACK_STATUS_DONE
 )
 
 func (s FileUploadCallbackStatus) String() string {
-	return [...]string{"CREATE_FILE", "FIRST_UPLOAD_CHUNK", "RETRY_UPLOAD_CHUNK", "VERIFY_CHUNK", "REPORT_COMPLETE"}[s]
+	return [...]string{"CREATE_FILE", "FIRST_UPLOAD_CHUNK", "RETRY_UPLOAD_CHUNK", "VERIFY_CHUNK", "REPORT_COMPLETE", "HASH_PROGRESS", "UPLOAD_PROGRESS", "DONE"}[s]
 }
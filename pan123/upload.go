@@ -0,0 +1,137 @@
+package pan123
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// FileUploadFromReaderAt 从io.ReaderAt上传文件, 例如: *bytes.Reader、*io.SectionReader
+//
+// @param parentFileID int64 父目录id, 上传到根目录时填写0
+//
+// @param filename string 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><。（注：不能重名）
+//
+// @param r io.ReaderAt 要上传的内容, 上传期间内容不可变更
+//
+// @param size int64 内容大小, 需大于0
+//
+// @param retry int 上传单一文件块时的重试次数, 0为不重试
+//
+// @param cb FileUploadCallbackFunc Callback, 可为nil
+//
+// @return FileUploadRespData
+//
+// @return SDKError
+func (p123 *Pan123) FileUploadFromReaderAt(parentFileID int64, filename string, r io.ReaderAt, size int64, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error) {
+	return p123.FileUploadFromReaderAtContext(context.Background(), parentFileID, filename, r, size, retry, cb)
+}
+
+// FileUploadFromReaderAtContext 同FileUploadFromReaderAt, 支持通过ctx控制超时与取消
+func (p123 *Pan123) FileUploadFromReaderAtContext(ctx context.Context, parentFileID int64, filename string, r io.ReaderAt, size int64, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error) {
+	progress := newUploadProgress(cb)
+	if err := validateFilename("filename", filename); err != nil {
+		progress.done(nil, err)
+		return nil, err
+	}
+	return p123.uploadContent(ctx, parentFileID, filename, r, size, time.Time{}, "", retry, progress)
+}
+
+// uploadContent 所有上传入口的公共流程, md5Sum为空时计算r的md5, modTime为零值时表示未知
+//
+// filename由各上传入口在读取内容前校验; 返回前发送FILE_UPLOAD_CALLBACK_STATUS_DONE
+func (p123 *Pan123) uploadContent(ctx context.Context, parentFileID int64, filename string, r io.ReaderAt, size int64, modTime time.Time, md5Sum string, retry int, progress *uploadProgress) (*FileUploadRespData, error) {
+	respData, err := p123.tracedUploadContent(ctx, parentFileID, filename, r, size, modTime, md5Sum, retry, progress)
+	progress.done(respData, err)
+	return respData, err
+}
+
+func (p123 *Pan123) tracedUploadContent(ctx context.Context, parentFileID int64, filename string, r io.ReaderAt, size int64, modTime time.Time, md5Sum string, retry int, progress *uploadProgress) (*FileUploadRespData, error) {
+	if size <= 0 {
+		return nil, newValidationError("size", "must be greater than 0")
+	}
+
+	ctx, span := p123.tracer.Start(ctx, "pan123 FileUpload", Attr(AttrParentFileID, parentFileID), Attr(AttrFilename, filename), Attr(AttrSize, size))
+	defer span.End()
+
+	respData, err := p123.fileUploadWithCallback(ctx, parentFileID, filename, r, size, modTime, md5Sum, retry, progress)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if respData.Async {
+		span.SetAttributes(Attr(AttrPreuploadID, respData.PreuploadID))
+	} else {
+		span.SetAttributes(Attr(AttrFileID, respData.FileID))
+	}
+	return respData, nil
+}
+
+// FileUploadFromReadSeeker 从io.ReadSeeker上传文件, 上传从起始位置开始, 大小通过Seek获取
+//
+// r同时实现io.ReaderAt时直接使用ReadAt, 否则通过Seek+Read读取, 上传期间调用方不可再使用r
+//
+// @param parentFileID int64 父目录id, 上传到根目录时填写0
+//
+// @param filename string 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><。（注：不能重名）
+//
+// @param r io.ReadSeeker 要上传的内容
+//
+// @param retry int 上传单一文件块时的重试次数, 0为不重试
+//
+// @param cb FileUploadCallbackFunc Callback, 可为nil
+//
+// @return FileUploadRespData
+//
+// @return SDKError
+func (p123 *Pan123) FileUploadFromReadSeeker(parentFileID int64, filename string, r io.ReadSeeker, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error) {
+	return p123.FileUploadFromReadSeekerContext(context.Background(), parentFileID, filename, r, retry, cb)
+}
+
+// FileUploadFromReadSeekerContext 同FileUploadFromReadSeeker, 支持通过ctx控制超时与取消
+func (p123 *Pan123) FileUploadFromReadSeekerContext(ctx context.Context, parentFileID int64, filename string, r io.ReadSeeker, retry int, cb FileUploadCallbackFunc) (*FileUploadRespData, error) {
+	progress := newUploadProgress(cb)
+	if err := validateFilename("filename", filename); err != nil {
+		progress.done(nil, err)
+		return nil, err
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		err = newKindError(ErrFileIO, fmt.Sprintf("content.Seek(io.SeekEnd) error: %s", err), err)
+		progress.done(nil, err)
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		err = newKindError(ErrFileIO, fmt.Sprintf("content.Seek(io.SeekStart) error: %s", err), err)
+		progress.done(nil, err)
+		return nil, err
+	}
+
+	readerAt, ok := r.(io.ReaderAt)
+	if !ok {
+		readerAt = &readSeekerAt{r: r}
+	}
+	return p123.uploadContent(ctx, parentFileID, filename, readerAt, size, time.Time{}, "", retry, progress)
+}
+
+// readSeekerAt 通过Seek+Read实现io.ReaderAt, 并发安全
+type readSeekerAt struct {
+	mu sync.Mutex
+	r  io.ReadSeeker
+}
+
+func (rs *readSeekerAt) ReadAt(p []byte, off int64) (int, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, err := rs.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(rs.r, p)
+	if err == io.ErrUnexpectedEOF {
+		// io.ReaderAt读取不足时返回io.EOF
+		err = io.EOF
+	}
+	return n, err
+}
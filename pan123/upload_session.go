@@ -0,0 +1,263 @@
+package pan123
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// UploadSession 可持久化的上传会话, 进程退出后可凭此续传, 无需重新创建上传任务及上传已完成的分块
+type UploadSession struct {
+	// 预上传ID
+	PreuploadID string `json:"preuploadID"`
+	// 分块大小
+	SliceSize int64 `json:"sliceSize"`
+	// 父目录id
+	ParentFileID int64 `json:"parentFileID"`
+	// 文件名
+	Filename string `json:"filename"`
+	// 文件大小
+	Size int64 `json:"size"`
+	// 文件md5
+	Etag string `json:"etag"`
+	// 文件修改时间, 仅通过*os.File上传时存在; 续传时修改时间一致则不再计算md5
+	ModTime time.Time `json:"modTime,omitempty"`
+	// 已上传的分块, 分块序号 -> 分块大小
+	CompletedParts map[int64]int64 `json:"completedParts"`
+	// 创建时间
+	CreatedAt time.Time `json:"createdAt"`
+	// 更新时间
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (s *UploadSession) clone() *UploadSession {
+	_s := *s
+	_s.CompletedParts = make(map[int64]int64, len(s.CompletedParts))
+	for k, v := range s.CompletedParts {
+		_s.CompletedParts[k] = v
+	}
+	return &_s
+}
+
+// UploadSessionStore 上传会话的持久化存储
+type UploadSessionStore interface {
+	// Load 读取会话, 不存在时返回nil, nil
+	Load(ctx context.Context, key string) (*UploadSession, error)
+	// Save 保存会话
+	Save(ctx context.Context, key string, session *UploadSession) error
+	// Delete 删除会话, 不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// WithUploadSessionStore 设置上传会话存储, 设置后上传支持断点续传, 默认不续传
+//
+// 上传前按 UploadSessionKey 读取会话, 文件大小、md5一致时通过list_upload_parts获取已上传的分块并跳过;
+// 会话在每个分块上传完成后更新, 上传完成或会话失效(例如预上传ID已过期、分块校验失败)时删除
+//
+// @param store UploadSessionStore 例如: NewMemoryUploadSessionStore、NewFileUploadSessionStore
+func WithUploadSessionStore(store UploadSessionStore) Option {
+	return func(p123 *Pan123) {
+		p123.uploadSessionStore = store
+	}
+}
+
+// UploadSessionKey 上传会话在UploadSessionStore中的key, 由父目录id、文件名及文件大小决定
+//
+// @param parentFileID int64 父目录id
+//
+// @param filename string 文件名
+//
+// @param size int64 文件大小
+//
+// @return string 32位十六进制字符串, 可用作文件名
+func UploadSessionKey(parentFileID int64, filename string, size int64) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%d/%s/%d", parentFileID, filename, size))))
+}
+
+// MemoryUploadSessionStore 进程内上传会话存储, 可在多个Pan123实例间共享
+type MemoryUploadSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*UploadSession
+}
+
+// NewMemoryUploadSessionStore 创建进程内上传会话存储
+//
+// @return *MemoryUploadSessionStore
+func NewMemoryUploadSessionStore() *MemoryUploadSessionStore {
+	return &MemoryUploadSessionStore{sessions: map[string]*UploadSession{}}
+}
+
+// Load 读取会话
+func (s *MemoryUploadSessionStore) Load(_ context.Context, key string) (*UploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[key]
+	if !ok {
+		return nil, nil
+	}
+	return session.clone(), nil
+}
+
+// Save 保存会话
+func (s *MemoryUploadSessionStore) Save(_ context.Context, key string, session *UploadSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[key] = session.clone()
+	return nil
+}
+
+// Delete 删除会话
+func (s *MemoryUploadSessionStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+	return nil
+}
+
+// FileUploadSessionStore 基于文件的上传会话存储, 每个会话为目录下的一个JSON文件({key}.json)
+type FileUploadSessionStore struct {
+	dir string
+}
+
+// NewFileUploadSessionStore 创建基于文件的上传会话存储
+//
+// @param dir string 会话文件所在目录, 不存在时在首次保存时创建
+//
+// @return *FileUploadSessionStore
+func NewFileUploadSessionStore(dir string) *FileUploadSessionStore {
+	return &FileUploadSessionStore{dir: dir}
+}
+
+func (s *FileUploadSessionStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// Load 读取会话, 文件不存在时返回nil, nil
+func (s *FileUploadSessionStore) Load(_ context.Context, key string) (*UploadSession, error) {
+	buf, err := ioutil.ReadFile(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, newKindError(ErrFileIO, fmt.Sprintf("ioutil.ReadFile(sessionFile) error: %s", err), err)
+	}
+	var session UploadSession
+	if err := json.Unmarshal(buf, &session); err != nil {
+		return nil, newKindError(ErrDecode, fmt.Sprintf("json.Unmarshal(sessionFile) error: %s", err), err)
+	}
+	return &session, nil
+}
+
+// Save 原子写入会话
+func (s *FileUploadSessionStore) Save(_ context.Context, key string, session *UploadSession) error {
+	buf, err := json.Marshal(session)
+	if err != nil {
+		return newKindError(ErrValidation, fmt.Sprintf("json.Marshal(session) error: %s", err), err)
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return newKindError(ErrFileIO, fmt.Sprintf("os.MkdirAll error: %s", err), err)
+	}
+	return writeFileAtomic(s.path(key), buf)
+}
+
+// Delete 删除会话文件
+func (s *FileUploadSessionStore) Delete(_ context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return newKindError(ErrFileIO, fmt.Sprintf("os.Remove(sessionFile) error: %s", err), err)
+	}
+	return nil
+}
+
+// uploadSessionTracker 上传过程中维护会话, store为nil时所有方法均为空操作
+type uploadSessionTracker struct {
+	p123  *Pan123
+	store UploadSessionStore
+	key   string
+
+	mu      sync.Mutex
+	session *UploadSession
+}
+
+// load 读取与文件匹配的会话, md5Sum为空时仅在修改时间一致时匹配, 修改时间未知时需计算md5后再次匹配
+func (t *uploadSessionTracker) load(ctx context.Context, size int64, modTime time.Time, md5Sum string) *UploadSession {
+	if t.store == nil {
+		return nil
+	}
+	session, err := t.store.Load(ctx, t.key)
+	if err != nil {
+		t.p123.log(ctx, LogLevelWarn, "pan123 load upload session failed", "key", t.key, "error", err)
+		return nil
+	}
+	if session == nil {
+		return nil
+	}
+	matched := session.Size == size && session.PreuploadID != "" && session.SliceSize > 0
+	if md5Sum != "" {
+		matched = matched && session.Etag == md5Sum
+	} else {
+		matched = matched && !modTime.IsZero() && session.ModTime.Equal(modTime)
+	}
+	if !matched {
+		return nil
+	}
+	return session
+}
+
+// start 开始跟踪会话并保存
+func (t *uploadSessionTracker) start(ctx context.Context, session *UploadSession) {
+	if t.store == nil {
+		return
+	}
+	if session.CompletedParts == nil {
+		session.CompletedParts = map[int64]int64{}
+	}
+	t.mu.Lock()
+	t.session = session
+	t.mu.Unlock()
+	t.save(ctx)
+}
+
+// partDone 记录已上传的分块并保存, 可被多个goroutine并发调用
+func (t *uploadSessionTracker) partDone(ctx context.Context, sliceNo, size int64) {
+	if t.store == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.session == nil {
+		t.mu.Unlock()
+		return
+	}
+	t.session.CompletedParts[sliceNo] = size
+	t.mu.Unlock()
+	t.save(ctx)
+}
+
+func (t *uploadSessionTracker) save(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session.UpdatedAt = time.Now()
+	// 保存失败不影响本次上传, 仅无法续传
+	if err := t.store.Save(ctx, t.key, t.session); err != nil {
+		t.p123.log(ctx, LogLevelWarn, "pan123 save upload session failed", "key", t.key, "error", err)
+	}
+}
+
+// discard 删除会话
+func (t *uploadSessionTracker) discard(ctx context.Context) {
+	if t.store == nil {
+		return
+	}
+	t.mu.Lock()
+	t.session = nil
+	t.mu.Unlock()
+	// 上传已结束, 不受调用方ctx取消的影响
+	if err := t.store.Delete(context.Background(), t.key); err != nil {
+		t.p123.log(ctx, LogLevelWarn, "pan123 delete upload session failed", "key", t.key, "error", err)
+	}
+}
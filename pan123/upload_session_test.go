@@ -0,0 +1,171 @@
+package pan123
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadSessionResume(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	store := NewFileUploadSessionStore(filepath.Join(t.TempDir(), "sessions"))
+
+	content := []byte("0123456789abcdefghij")
+	path := filepath.Join(t.TempDir(), "resume.txt")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// 第3块上传失败, 模拟进程中断
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadSessionStore(store),
+		WithChunkMiddleware(func(next ChunkHandler) ChunkHandler {
+			return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+				if req.SliceNo == 3 {
+					return nil, errors.New("connection reset")
+				}
+				return next(ctx, req)
+			}
+		}))
+	p123.SetAccessToken("token")
+	if _, err := p123.FileUpload(0, "resume.txt", file, 0); !errors.Is(err, ErrUploadFailed) {
+		t.Fatalf("err = %v, want ErrUploadFailed", err)
+	}
+	key := UploadSessionKey(0, "resume.txt", int64(len(content)))
+	session, err := store.Load(context.Background(), key)
+	if err != nil || session == nil {
+		t.Fatalf("session = %v, %v", session, err)
+	}
+	if len(session.CompletedParts) != 2 || session.CompletedParts[1] != 4 || session.CompletedParts[2] != 4 {
+		t.Fatalf("completed parts = %v", session.CompletedParts)
+	}
+
+	// 新的实例续传, 只上传剩余的分块
+	chunkRequests := srv.RequestCount("/upload-chunk/")
+	p123 = NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadSessionStore(store))
+	p123.SetAccessToken("token")
+	var firsts []int64
+	resp, err := p123.FileUploadWithCallback(0, "resume.txt", file, 0, func(info FileUploadCallbackInfo) {
+		if info.Status == FILE_UPLOAD_CALLBACK_STATUS_FIRST_UPLOAD_CHUNK {
+			firsts = append(firsts, info.ChunkID)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := srv.FileContent(resp.FileID); !bytes.Equal(got, content) {
+		t.Errorf("content = %q", got)
+	}
+	if n := srv.RequestCount("/upload/v1/file/create"); n != 1 {
+		t.Errorf("create requests = %d, want 1", n)
+	}
+	if n := srv.RequestCount("/upload-chunk/") - chunkRequests; n != 3 {
+		t.Errorf("resumed chunk requests = %d, want 3", n)
+	}
+	if fmt.Sprint(firsts) != "[3 4 5]" {
+		t.Errorf("FIRST_UPLOAD_CHUNK = %v, want [3 4 5]", firsts)
+	}
+	if session, _ := store.Load(context.Background(), key); session != nil {
+		t.Errorf("session not deleted: %+v", session)
+	}
+}
+
+func TestUploadSessionResumeReaderAt(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	store := NewMemoryUploadSessionStore()
+	content := []byte("0123456789abcdefghij")
+
+	// 第3块上传失败, 模拟进程中断
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadSessionStore(store),
+		WithChunkMiddleware(func(next ChunkHandler) ChunkHandler {
+			return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+				if req.SliceNo == 3 {
+					return nil, errors.New("connection reset")
+				}
+				return next(ctx, req)
+			}
+		}))
+	p123.SetAccessToken("token")
+	if _, err := p123.FileUploadFromReaderAt(0, "resume.txt", bytes.NewReader(content), int64(len(content)), 0, nil); !errors.Is(err, ErrUploadFailed) {
+		t.Fatalf("err = %v, want ErrUploadFailed", err)
+	}
+
+	// 修改时间未知, 计算md5后匹配会话并续传
+	chunkRequests := srv.RequestCount("/upload-chunk/")
+	p123 = NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadSessionStore(store))
+	p123.SetAccessToken("token")
+	resp, err := p123.FileUploadFromReaderAt(0, "resume.txt", bytes.NewReader(content), int64(len(content)), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := srv.FileContent(resp.FileID); !bytes.Equal(got, content) {
+		t.Errorf("content = %q", got)
+	}
+	if n := srv.RequestCount("/upload/v1/file/create"); n != 1 {
+		t.Errorf("create requests = %d, want 1", n)
+	}
+	if n := srv.RequestCount("/upload-chunk/") - chunkRequests; n != 3 {
+		t.Errorf("resumed chunk requests = %d, want 3", n)
+	}
+}
+
+func TestUploadSessionExpired(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	store := NewMemoryUploadSessionStore()
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadSessionStore(store))
+	p123.SetAccessToken("token")
+
+	content := []byte("expired session")
+	key := UploadSessionKey(0, "expired.txt", int64(len(content)))
+	_ = store.Save(context.Background(), key, &UploadSession{
+		PreuploadID:    "unknown-preupload",
+		SliceSize:      4,
+		Size:           int64(len(content)),
+		Etag:           "e2ae4bc5ae5d8a1da2c7e5fc7dcc7b6b",
+		CompletedParts: map[int64]int64{1: 4},
+	})
+	// md5与会话不一致, 不使用会话
+	resp, err := p123.FileUploadFromReaderAt(0, "expired.txt", bytes.NewReader(content), int64(len(content)), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := srv.FileContent(resp.FileID); !bytes.Equal(got, content) {
+		t.Errorf("content = %q", got)
+	}
+
+	// md5一致但预上传ID已失效, 重新创建
+	content = []byte("expired session again")
+	key = UploadSessionKey(0, "expired2.txt", int64(len(content)))
+	etag, err := fileUploadHash(context.Background(), bytes.NewReader(content), int64(len(content)), newUploadProgress(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Save(context.Background(), key, &UploadSession{
+		PreuploadID:    "unknown-preupload",
+		SliceSize:      4,
+		Size:           int64(len(content)),
+		Etag:           etag,
+		CompletedParts: map[int64]int64{1: 4},
+	})
+	resp, err = p123.FileUploadFromReaderAt(0, "expired2.txt", bytes.NewReader(content), int64(len(content)), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := srv.FileContent(resp.FileID); !bytes.Equal(got, content) {
+		t.Errorf("content = %q", got)
+	}
+	if session, _ := store.Load(context.Background(), key); session != nil {
+		t.Errorf("session not deleted: %+v", session)
+	}
+}
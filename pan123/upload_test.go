@@ -0,0 +1,289 @@
+package pan123
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// seekOnly 仅实现io.ReadSeeker, 用于测试Seek+Read读取
+type seekOnly struct {
+	r *bytes.Reader
+}
+
+func (s *seekOnly) Read(p []byte) (int, error) { return s.r.Read(p) }
+func (s *seekOnly) Seek(offset int64, whence int) (int64, error) {
+	return s.r.Seek(offset, whence)
+}
+
+func TestFileUploadFromReaderAt(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit())
+	p123.SetAccessToken("token")
+
+	content := []byte("0123456789abcdef!")
+	// 只上传中间的一段
+	section := io.NewSectionReader(bytes.NewReader(content), 2, 10)
+	resp, err := p123.FileUploadFromReaderAt(0, "section.txt", section, section.Size(), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := srv.FileContent(resp.FileID)
+	if !ok || string(got) != "23456789ab" {
+		t.Errorf("content = %q, %v", got, ok)
+	}
+
+	// size大于实际内容
+	_, err = p123.FileUploadFromReaderAt(0, "short.txt", bytes.NewReader(content), int64(len(content)+1), 0, nil)
+	if !errors.Is(err, ErrFileIO) {
+		t.Errorf("err = %v, want ErrFileIO", err)
+	}
+
+	_, err = p123.FileUploadFromReaderAt(0, "empty.txt", bytes.NewReader(nil), 0, 0, nil)
+	var sdkErr *SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Field != "size" {
+		t.Errorf("err = %v, want size validation error", err)
+	}
+}
+
+func TestFileUploadFromReadSeeker(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit())
+	p123.SetAccessToken("token")
+
+	content := []byte("hello read seeker")
+	for name, r := range map[string]io.ReadSeeker{
+		"reader_at.txt": bytes.NewReader(content),
+		"seek_only.txt": &seekOnly{r: bytes.NewReader(content)},
+	} {
+		// 当前位置不影响上传的内容
+		if _, err := r.Seek(5, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := p123.FileUploadFromReadSeeker(0, name, r, 0, nil)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		got, ok := srv.FileContent(resp.FileID)
+		if !ok || !bytes.Equal(got, content) {
+			t.Errorf("%s: content = %q, %v", name, got, ok)
+		}
+	}
+}
+
+func TestFileUploadConcurrency(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	var inflight, maxInflight int32
+	var failed int32
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadConcurrency(4),
+		WithChunkMiddleware(func(next ChunkHandler) ChunkHandler {
+			return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+				n := atomic.AddInt32(&inflight, 1)
+				defer atomic.AddInt32(&inflight, -1)
+				for {
+					m := atomic.LoadInt32(&maxInflight)
+					if n <= m || atomic.CompareAndSwapInt32(&maxInflight, m, n) {
+						break
+					}
+				}
+				// 第5块首次上传失败
+				if req.SliceNo == 5 && atomic.CompareAndSwapInt32(&failed, 0, 1) {
+					return nil, errors.New("connection reset")
+				}
+				time.Sleep(10 * time.Millisecond)
+				return next(ctx, req)
+			}
+		}))
+	p123.SetAccessToken("token")
+
+	var (
+		mu       sync.Mutex
+		busy     bool
+		firsts   []int64
+		retries  []int64
+		parallel bool
+	)
+	cb := func(info FileUploadCallbackInfo) {
+		mu.Lock()
+		if busy {
+			parallel = true
+		}
+		busy = true
+		mu.Unlock()
+		switch info.Status {
+		case FILE_UPLOAD_CALLBACK_STATUS_FIRST_UPLOAD_CHUNK:
+			firsts = append(firsts, info.ChunkID)
+		case FILE_UPLOAD_CALLBACK_STATUS_RETRY_UPLOAD_CHUNK:
+			retries = append(retries, info.ChunkID)
+		}
+		mu.Lock()
+		busy = false
+		mu.Unlock()
+	}
+
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz!")
+	resp, err := p123.FileUploadFromReaderAt(0, "parallel.txt", bytes.NewReader(content), int64(len(content)), 1, cb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := srv.FileContent(resp.FileID)
+	if !ok || !bytes.Equal(got, content) {
+		t.Errorf("content = %q, %v", got, ok)
+	}
+	if maxInflight < 2 || maxInflight > 4 {
+		t.Errorf("max in-flight chunks = %d, want 2..4", maxInflight)
+	}
+	if parallel {
+		t.Error("callback called concurrently")
+	}
+	if len(firsts) != 10 {
+		t.Fatalf("FIRST_UPLOAD_CHUNK = %v", firsts)
+	}
+	for i, id := range firsts {
+		if id != int64(i+1) {
+			t.Fatalf("FIRST_UPLOAD_CHUNK out of order: %v", firsts)
+		}
+	}
+	if len(retries) != 1 || retries[0] != 5 {
+		t.Errorf("RETRY_UPLOAD_CHUNK = %v, want [5]", retries)
+	}
+}
+
+func TestFileUploadConcurrencyFailure(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadConcurrency(3),
+		WithChunkMiddleware(func(next ChunkHandler) ChunkHandler {
+			return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+				if req.SliceNo == 2 {
+					return nil, errors.New("connection reset")
+				}
+				return next(ctx, req)
+			}
+		}))
+	p123.SetAccessToken("token")
+
+	content := bytes.Repeat([]byte("x"), 40)
+	_, err := p123.FileUploadFromReaderAt(0, "fail.txt", bytes.NewReader(content), int64(len(content)), 2, nil)
+	if !errors.Is(err, ErrUploadFailed) {
+		t.Fatalf("err = %v, want ErrUploadFailed", err)
+	}
+	if errors.Is(err, ErrCanceled) {
+		t.Errorf("err = %v, should report the chunk failure rather than cancellation", err)
+	}
+}
+
+func TestFileUploadSliceRetryBackoff(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	defer func(policy RetryPolicy) { chunkRetryPolicy = policy }(chunkRetryPolicy)
+	chunkRetryPolicy = RetryPolicy{InitialBackoff: 50 * time.Millisecond, Multiplier: 2}
+
+	var attempts []time.Time
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(),
+		WithChunkMiddleware(func(next ChunkHandler) ChunkHandler {
+			return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+				attempts = append(attempts, time.Now())
+				return nil, errors.New("connection reset")
+			}
+		}))
+	p123.SetAccessToken("token")
+
+	content := []byte("backoff")
+	if _, err := p123.FileUploadFromReaderAt(0, "backoff.txt", bytes.NewReader(content), int64(len(content)), 2, nil); !errors.Is(err, ErrUploadFailed) {
+		t.Fatalf("err = %v, want ErrUploadFailed", err)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(attempts))
+	}
+	// 等待时间依次为50ms、100ms
+	for i, want := range []time.Duration{50 * time.Millisecond, 100 * time.Millisecond} {
+		if d := attempts[i+1].Sub(attempts[i]); d < want {
+			t.Errorf("backoff before attempt %d = %s, want >= %s", i+2, d, want)
+		}
+	}
+
+	// 等待期间ctx取消时立即返回
+	chunkRetryPolicy = RetryPolicy{InitialBackoff: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := p123.FileUploadFromReaderAtContext(ctx, 0, "backoff.txt", bytes.NewReader(content), int64(len(content)), 2, nil); !errors.Is(err, ErrCanceled) {
+		t.Fatalf("err = %v, want ErrCanceled", err)
+	}
+}
+
+func TestFileUploadSliceBufferNotReusedBeforeClose(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	// 中间件替换第1块的请求体且不关闭原请求体, 原请求体引用的缓冲区不可被后续分块覆盖
+	var held *bytes.Reader
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(),
+		WithChunkMiddleware(func(next ChunkHandler) ChunkHandler {
+			return func(ctx context.Context, req *ChunkRequest) (*ChunkResponse, error) {
+				if req.SliceNo == 1 {
+					held = req.Body.(*progressReader).r.(*bytes.Reader)
+					data, err := ioutil.ReadAll(req.Body)
+					if err != nil {
+						return nil, err
+					}
+					req.Body = bytes.NewReader(data)
+				}
+				return next(ctx, req)
+			}
+		}))
+	p123.SetAccessToken("token")
+
+	content := []byte("0123456789abcdefghij")
+	if _, err := p123.FileUploadFromReaderAt(0, "buffer.txt", bytes.NewReader(content), int64(len(content)), 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := held.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadAll(held); string(got) != "0123" {
+		t.Errorf("slice 1 body = %q, want %q", got, "0123")
+	}
+}
+
+// go test -race -run TestFileUploadConcurrencyResponseMeta ./pan123
+func TestFileUploadConcurrencyResponseMeta(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	var workerMeta int32
+	p123 := NewPan123WithOptions(WithBaseURL(srv.URL), WithoutRateLimit(), WithUploadConcurrency(4),
+		WithMiddleware(func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				// 并发获取分块上传地址时不应写入调用方的meta
+				if req.Endpoint == "/upload/v1/file/get_upload_url" && responseMetaFromContext(ctx) != nil {
+					atomic.AddInt32(&workerMeta, 1)
+				}
+				return next(ctx, req)
+			}
+		}))
+	p123.SetAccessToken("token")
+
+	var meta ResponseMeta
+	ctx := ContextWithResponseMeta(context.Background(), &meta)
+	content := bytes.Repeat([]byte("meta"), 20)
+	if _, err := p123.FileUploadFromReaderAtContext(ctx, 0, "meta.txt", bytes.NewReader(content), int64(len(content)), 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if workerMeta != 0 {
+		t.Errorf("%d get_upload_url calls wrote the caller's ResponseMeta", workerMeta)
+	}
+	// 最后一次调用为upload_complete
+	if meta.Endpoint != "/upload/v1/file/upload_complete" {
+		t.Errorf("meta.Endpoint = %q", meta.Endpoint)
+	}
+}
@@ -0,0 +1,127 @@
+package pan123
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// 文件名的最大长度(不含), 按字符计
+	maxFilenameLength = 128
+	// 文件名不能包含的字符
+	invalidFilenameChars = "\"\\/:*?|><"
+	// 批量操作单次最多支持的文件ID数量
+	maxBatchFileIDs = 100
+	// 文件列表每页最多支持的文件数量
+	maxListLimit = 100
+	// 单次重命名最多支持的文件数量
+	maxRenameCount = 30
+)
+
+// newValidationError 参数校验失败, field为参数名
+func newValidationError(field, format string, args ...interface{}) error {
+	sdkErr := new(SDKError)
+	sdkErr.Code = SDKErrorCodeInternal
+	sdkErr.Message = fmt.Sprintf("%s invalid: %s", field, fmt.Sprintf(format, args...))
+	sdkErr.TraceID = defaultTraceID
+	sdkErr.Kind = ErrValidation
+	sdkErr.Field = field
+	return sdkErr
+}
+
+// validateFilename 文件名要小于128个字符且不能包含以下任何字符："\/:*?|><
+func validateFilename(field, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return newValidationError(field, "empty")
+	}
+	if utf8.RuneCountInString(name) >= maxFilenameLength {
+		return newValidationError(field, "length must be less than %d", maxFilenameLength)
+	}
+	if i := strings.IndexAny(name, invalidFilenameChars); i != -1 {
+		return newValidationError(field, "contains invalid character %q", name[i])
+	}
+	return nil
+}
+
+// validateFileIDs 文件ID数量需在1-100之间
+func validateFileIDs(field string, fileIDs []int64) error {
+	if len(fileIDs) == 0 {
+		return newValidationError(field, "empty")
+	}
+	if len(fileIDs) > maxBatchFileIDs {
+		return newValidationError(field, "at most %d ids, got %d", maxBatchFileIDs, len(fileIDs))
+	}
+	return nil
+}
+
+// validateFileIDList 以逗号分割的文件ID列表, 数量需在1-100之间
+func validateFileIDList(field, fileIDList string) error {
+	ids := strings.Split(fileIDList, ",")
+	if len(ids) > maxBatchFileIDs {
+		return newValidationError(field, "at most %d ids, got %d", maxBatchFileIDs, len(ids))
+	}
+	for _, id := range ids {
+		if _, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64); err != nil {
+			return newValidationError(field, "%q is not a file id", id)
+		}
+	}
+	return nil
+}
+
+// validateLimit 每页文件数量需在1-100之间
+func validateLimit(field string, limit int64) error {
+	if limit <= 0 || limit > maxListLimit {
+		return newValidationError(field, "must be between 1 and %d, got %d", maxListLimit, limit)
+	}
+	return nil
+}
+
+// validatePage 页码从1开始
+func validatePage(field string, page int64) error {
+	if page < 1 {
+		return newValidationError(field, "must be greater than or equal to 1, got %d", page)
+	}
+	return nil
+}
+
+// validateRenameList 每个成员的格式为 文件ID|新的文件名
+func validateRenameList(field string, renameList []string) error {
+	if len(renameList) == 0 {
+		return newValidationError(field, "empty")
+	}
+	if len(renameList) > maxRenameCount {
+		return newValidationError(field, "at most %d items, got %d", maxRenameCount, len(renameList))
+	}
+	for i, item := range renameList {
+		itemField := fmt.Sprintf("%s[%d]", field, i)
+		parts := strings.SplitN(item, "|", 2)
+		if len(parts) != 2 {
+			return newValidationError(itemField, "%q must be in the form id|name", item)
+		}
+		if _, err := strconv.ParseInt(parts[0], 10, 64); err != nil {
+			return newValidationError(itemField, "%q must be in the form id|name", item)
+		}
+		if err := validateFilename(itemField, parts[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateHTTPURL 仅支持http/https地址
+func validateHTTPURL(field, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return newValidationError(field, "%s", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return newValidationError(field, "scheme must be http or https")
+	}
+	if u.Host == "" {
+		return newValidationError(field, "missing host")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package pan123
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failTransport 校验失败时不应发起任何请求
+type failTransport struct {
+	t *testing.T
+}
+
+func (ft failTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ft.t.Errorf("unexpected request %s %s", req.Method, req.URL)
+	return nil, errors.New("unexpected request")
+}
+
+func TestValidation(t *testing.T) {
+	p123 := NewPan123WithOptions(WithTransport(failTransport{t}), WithoutRateLimit())
+	p123.SetAccessToken("token")
+
+	tooManyIDs := make([]int64, maxBatchFileIDs+1)
+	ids := make([]string, maxBatchFileIDs+1)
+	for i := range ids {
+		ids[i] = "1"
+	}
+	file, err := os.Create(filepath.Join(t.TempDir(), "upload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tests := []struct {
+		name  string
+		field string
+		call  func() error
+	}{
+		{"mkdir empty name", "name", func() error {
+			_, err := p123.MkDir("", 0)
+			return err
+		}},
+		{"mkdir invalid char", "name", func() error {
+			_, err := p123.MkDir("a/b", 0)
+			return err
+		}},
+		{"upload long filename", "filename", func() error {
+			_, err := p123.FileUpload(0, strings.Repeat("文", maxFilenameLength), file, 0)
+			return err
+		}},
+		{"upload invalid char", "filename", func() error {
+			_, err := p123.FileUpload(0, "a?.txt", file, 0)
+			return err
+		}},
+		{"upload empty file", "file", func() error {
+			_, err := p123.FileUpload(0, "a.txt", file, 0)
+			return err
+		}},
+		{"move empty", "fileIDs", func() error {
+			return p123.MoveFile(nil, 0)
+		}},
+		{"move too many", "fileIDs", func() error {
+			return p123.MoveFile(tooManyIDs, 0)
+		}},
+		{"trash too many", "fileIDs", func() error {
+			return p123.TrashFile(tooManyIDs)
+		}},
+		{"recover too many", "fileIDs", func() error {
+			return p123.RecoverFile(tooManyIDs)
+		}},
+		{"delete too many", "fileIDs", func() error {
+			return p123.DeleteFile(tooManyIDs)
+		}},
+		{"list page", "page", func() error {
+			_, err := p123.GetFileList(0, 0, 100, "file_id", "asc", false, "")
+			return err
+		}},
+		{"list limit", "limit", func() error {
+			_, err := p123.GetFileList(0, 1, 101, "file_id", "asc", false, "")
+			return err
+		}},
+		{"list orderBy", "orderBy", func() error {
+			_, err := p123.GetFileList(0, 1, 100, "name", "asc", false, "")
+			return err
+		}},
+		{"list v2 limit", "limit", func() error {
+			_, err := p123.GetFileListV2(0, 0, "", -1, -1)
+			return err
+		}},
+		{"list v2 searchMode", "searchMode", func() error {
+			_, err := p123.GetFileListV2(0, 100, "a", 2, -1)
+			return err
+		}},
+		{"rename format", "renameList[0]", func() error {
+			return p123.RenameFile([]string{"1-a.txt"})
+		}},
+		{"rename format index", "renameList[1]", func() error {
+			return p123.RenameFile([]string{"1|a.txt", "2-b.txt"})
+		}},
+		{"rename id", "renameList[0]", func() error {
+			return p123.RenameFile([]string{"a|a.txt"})
+		}},
+		{"rename filename", "renameList[1]", func() error {
+			return p123.RenameFile([]string{"1|a.txt", "2|a*.txt"})
+		}},
+		{"share name", "shareName", func() error {
+			_, err := p123.CreateShare("", "1", "", 0)
+			return err
+		}},
+		{"share id list", "fileIDList", func() error {
+			_, err := p123.CreateShare("share", "1,a", "", 0)
+			return err
+		}},
+		{"share too many", "fileIDList", func() error {
+			_, err := p123.CreateShare("share", strings.Join(ids, ","), "", 0)
+			return err
+		}},
+		{"share expire", "shareExpire", func() error {
+			_, err := p123.CreateShare("share", "1", "", 3)
+			return err
+		}},
+		{"offline scheme", "url", func() error {
+			_, err := p123.OfflineDownload("ftp://example.com/a.zip", "", "", 0)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("err = %v, want ErrValidation", err)
+			}
+			var sdkErr *SDKError
+			if !errors.As(err, &sdkErr) || sdkErr.Field != tt.field {
+				t.Errorf("field = %q, want %q (%v)", sdkErr.Field, tt.field, err)
+			}
+		})
+	}
+}
+
+func TestValidateFilename(t *testing.T) {
+	for _, name := range []string{"a.txt", "中文文件名.mp4", strings.Repeat("文", maxFilenameLength-1)} {
+		if err := validateFilename("filename", name); err != nil {
+			t.Errorf("validateFilename(%q) = %v", name, err)
+		}
+	}
+	for _, c := range invalidFilenameChars {
+		if err := validateFilename("filename", "a"+string(c)+"b"); err == nil {
+			t.Errorf("validateFilename accepted %q", c)
+		}
+	}
+}